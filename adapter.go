@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 )
 
 var (
@@ -16,8 +18,181 @@ var (
 	ErrUniqueViolation     = errors.New("violated the unique constraint")
 	ErrNotNullViolation    = errors.New("violated the not null constraint")
 	ErrForeignKeyViolation = errors.New("violated the foreign key constraint")
+	ErrExclusionViolation  = errors.New("violated the exclusion constraint")
+
+	ErrSerializationFailure = errors.New("could not serialize access")
+	ErrDeadlockDetected     = errors.New("deadlock detected")
+	ErrLockNotAvailable     = errors.New("lock not available")
+	ErrQueryCanceled        = errors.New("query canceled")
+	ErrQueryTimeout         = errors.New("query timed out")
+
+	ErrStringDataRightTruncation = errors.New("string data right truncation")
+	ErrNumericValueOutOfRange    = errors.New("numeric value out of range")
+	ErrInvalidTextRepresentation = errors.New("invalid text representation")
+
+	ErrReadOnlyTransaction   = errors.New("read-only transaction")
+	ErrInsufficientPrivilege = errors.New("insufficient privilege")
+	ErrUndefinedTable        = errors.New("undefined table")
+	ErrUndefinedColumn       = errors.New("undefined column")
+
+	ErrNoTx       = errors.New("no transaction in the context")
+	ErrTxDone     = errors.New("transaction is already done")
+	ErrTxExpired  = errors.New("transaction has expired")
+	ErrConnClosed = errors.New("connection is closed")
+	ErrConnBusy   = errors.New("connection is busy")
+	// ErrTxRolledBack is returned by Commit if the transaction
+	// has been rolled back instead, because an earlier statement failed.
+	ErrTxRolledBack = errors.New("transaction has been rolled back")
+
+	ErrUnsupportedTxOptions = errors.New("unsupported transaction options")
+
+	ErrInvalidSavepoint = errors.New("invalid savepoint name")
+	ErrUnknownSavepoint = errors.New("unknown savepoint")
+
+	ErrInvalidGlobalID   = errors.New("invalid global transaction id")
+	ErrUnknownGlobalID   = errors.New("unknown global transaction id")
+	ErrDuplicateGlobalID = errors.New("duplicate global transaction id")
+	ErrPrepareNestedTx   = errors.New("cannot prepare a nested transaction")
+
+	ErrSnapshotInvalid = errors.New("invalid snapshot")
+)
+
+// ConstraintError describes a violated integrity constraint.
+// It unwraps to one of the constraint violation errors above.
+type ConstraintError struct {
+	// Err is the constraint violation error, e.g. ErrUniqueViolation.
+	Err error
+	// Code is the SQLSTATE code reported by the database.
+	Code       string
+	Constraint string
+	Table      string
+	Column     string
+	Detail     string
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Constraint == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s %q", e.Err.Error(), e.Constraint)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether the operation that failed with err
+// can be run again. For ErrSerializationFailure and ErrDeadlockDetected
+// that means running the whole transaction again.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrSerializationFailure) ||
+		errors.Is(err, ErrDeadlockDetected) {
+		return true
+	}
+
+	var e interface{ Retryable() bool }
+	return errors.As(err, &e) && e.Retryable()
+}
+
+// IsTransient reports whether err is caused by a condition
+// that is likely to go away by itself.
+func IsTransient(err error) bool {
+	var e interface{ Transient() bool }
+	return errors.As(err, &e) && e.Transient()
+}
+
+// IsConnectionError reports whether err is caused by a failed
+// or a lost connection to the database.
+func IsConnectionError(err error) bool {
+	var e interface{ ConnectionError() bool }
+	return errors.As(err, &e) && e.ConnectionError()
+}
+
+// IsTimeout reports whether err is caused by an expired deadline.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var e interface{ Timeout() bool }
+	return errors.As(err, &e) && e.Timeout()
+}
+
+// IsolationLevel is the isolation level of a transaction.
+// The zero value is the default level of the database.
+type IsolationLevel int
+
+const (
+	LevelDefault IsolationLevel = iota
+	LevelReadUncommitted
+	LevelReadCommitted
+	LevelRepeatableRead
+	LevelSerializable
 )
 
+func (l IsolationLevel) String() string {
+	switch l {
+	case LevelDefault:
+		return "default"
+	case LevelReadUncommitted:
+		return "read uncommitted"
+	case LevelReadCommitted:
+		return "read committed"
+	case LevelRepeatableRead:
+		return "repeatable read"
+	case LevelSerializable:
+		return "serializable"
+	}
+	return fmt.Sprintf("IsolationLevel(%d)", int(l))
+}
+
+// AccessMode is the access mode of a transaction.
+// The zero value is the default mode of the database.
+type AccessMode int
+
+const (
+	AccessModeDefault AccessMode = iota
+	AccessModeReadWrite
+	AccessModeReadOnly
+)
+
+func (m AccessMode) String() string {
+	switch m {
+	case AccessModeDefault:
+		return "default"
+	case AccessModeReadWrite:
+		return "read write"
+	case AccessModeReadOnly:
+		return "read only"
+	}
+	return fmt.Sprintf("AccessMode(%d)", int(m))
+}
+
+// TxOptions are the options of a transaction.
+// The zero value begins a transaction with the defaults of the database.
+type TxOptions struct {
+	Isolation  IsolationLevel
+	AccessMode AccessMode
+	// Deferrable only has an effect on serializable read-only transactions,
+	// which then wait for a snapshot that cannot cause serialization
+	// failures.
+	Deferrable bool
+	// Settings are the run-time parameters, e.g. "app.tenant_id",
+	// set for the transaction only, as if by SET LOCAL. They are meant
+	// for the row-level security policies that read them
+	// with current_setting.
+	Settings map[string]string
+	// Role is the role the transaction runs as, as if by SET LOCAL ROLE.
+	// Empty Role keeps the role of the session.
+	Role string
+	// Snapshot is the id of a snapshot exported by another transaction,
+	// which must still be open, see Tx.ExportSnapshot. The transaction
+	// then sees the same data as the exporting one. It requires
+	// the repeatable read or the serializable isolation level, and
+	// the default level is raised to repeatable read.
+	Snapshot string
+}
+
 type Result interface {
 	sql.Result
 }
@@ -41,22 +216,105 @@ type Stmt interface {
 	Close() error
 }
 
-type Conn interface {
+// Querier is implemented by both Conn and Tx, so that the code
+// that runs queries does not depend on whether it runs in a transaction.
+// See QuerierFromContext.
+type Querier interface {
 	Exec(ctx context.Context, query string, args ...any) (Result, error)
 	Query(ctx context.Context, query string, args ...any) (Rows, error)
 	QueryRow(ctx context.Context, query string, args ...any) Row
 	Prepare(ctx context.Context, query string) (Stmt, error)
 	Begin(ctx context.Context) (Tx, error)
+	// BeginTx begins a nested transaction if called on a Tx.
+	// A nested transaction cannot have options of its own,
+	// so opts must be zero.
+	BeginTx(ctx context.Context, opts TxOptions) (Tx, error)
+}
+
+// PreparedTx is a transaction prepared for two-phase commit.
+type PreparedTx struct {
+	// GlobalID is the id the transaction has been prepared with.
+	GlobalID string
+	Prepared time.Time
+	Owner    string
+	Database string
+}
+
+type Conn interface {
+	Querier
+	// CommitPrepared commits the transaction prepared with the global id,
+	// possibly by another connection.
+	CommitPrepared(ctx context.Context, globalID string) error
+	// RollbackPrepared rolls back the transaction prepared
+	// with the global id, possibly by another connection.
+	RollbackPrepared(ctx context.Context, globalID string) error
+	// ListPrepared returns the prepared transactions that are waiting
+	// to be committed or rolled back, from the oldest to the newest.
+	ListPrepared(ctx context.Context) ([]PreparedTx, error)
 	Ping(ctx context.Context) error
 	Close() error
 }
 
+// PoolStat is a snapshot of the statistics of a connection pool.
+type PoolStat struct {
+	MaxConns int
+	// TotalConns is the number of the connections that are acquired,
+	// idle or being established.
+	TotalConns        int
+	AcquiredConns     int
+	IdleConns         int
+	ConstructingConns int
+
+	// AcquireCount is the number of the successful acquires,
+	// and AcquireDuration is the total time they have taken.
+	AcquireCount    int64
+	AcquireDuration time.Duration
+	// EmptyAcquireCount is the number of the successful acquires
+	// that have had to wait for a connection.
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+}
+
+// Pool is a Conn that runs every call on a connection
+// acquired from a pool of connections.
+type Pool interface {
+	Conn
+	// Acquire pins a connection of the pool for the work that needs
+	// every statement to run in the same session, such as LISTEN,
+	// session advisory locks, temporary tables or SET without LOCAL.
+	Acquire(ctx context.Context) (PinnedConn, error)
+	Stat() PoolStat
+}
+
+// PinnedConn is a connection acquired from a pool,
+// which stays out of the pool until it is released.
+type PinnedConn interface {
+	Conn
+	// Release resets the state of the session and returns
+	// the connection to the pool. Using the connection afterwards
+	// fails with ErrConnClosed.
+	Release(ctx context.Context) error
+}
+
 type Tx interface {
-	Exec(ctx context.Context, query string, args ...any) (Result, error)
-	Query(ctx context.Context, query string, args ...any) (Rows, error)
-	QueryRow(ctx context.Context, query string, args ...any) Row
-	Prepare(ctx context.Context, query string) (Stmt, error)
-	Begin(ctx context.Context) (Tx, error)
+	Querier
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
+	// Savepoint creates a named savepoint. The names are case-sensitive.
+	Savepoint(ctx context.Context, name string) error
+	// RollbackTo rolls back to the most recent savepoint with the name,
+	// which remains open. The savepoints created after it are released.
+	RollbackTo(ctx context.Context, name string) error
+	// Release releases the most recent savepoint with the name
+	// and the savepoints created after it.
+	Release(ctx context.Context, name string) error
+	// PrepareTransaction prepares the transaction for two-phase commit
+	// with the global id, which is unique across the database cluster.
+	// The transaction is done afterwards, and has to be committed
+	// or rolled back with the id through Conn.
+	PrepareTransaction(ctx context.Context, globalID string) error
+	// ExportSnapshot returns the id of the snapshot of the transaction,
+	// which other transactions can import through TxOptions.Snapshot
+	// while the transaction is open.
+	ExportSnapshot(ctx context.Context) (string, error)
 }
@@ -1,17 +1,24 @@
 package pgxadapt
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	adapter "github.com/adanyl0v/go-sql-adapter"
 	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver"
-	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/errs"
 	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
-	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // Result
@@ -44,32 +51,58 @@ func (r Result) RowsAffected() (int64, error) {
 type Row struct {
 	driverRow driver.Row
 	tracer    trace.Logger
+	opts      *options
+	query     string
+	err       error
+	// release releases the connection once the row is scanned.
+	release func()
 }
 
-func NewRow(driverRow driver.Row, tracer trace.Logger) Row {
+func NewRow(driverRow driver.Row, tracer trace.Logger, opts ...Option) Row {
+	return newRow(driverRow, tracer, newOptions(opts), "", func() {})
+}
+
+func newRow(
+	driverRow driver.Row,
+	tracer trace.Logger,
+	opts *options,
+	query string,
+	release func(),
+) Row {
 	return Row{
 		driverRow: driverRow,
 		tracer:    tracer,
+		opts:      opts,
+		query:     query,
+		release:   sync.OnceFunc(release),
 	}
 }
 
-// Err always returns nil.
+// Err returns the error that prevented the query from being run.
+// Errors of the query itself are returned by Scan,
+// unless the row has been read eagerly. See WithEagerQueryRow.
 func (r Row) Err() error {
-	return nil
+	return r.err
 }
 
 func (r Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	start := time.Now()
 	err := r.driverRow.Scan(dest...)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			err = errs.New(adapter.ErrNoRows.Error(), err)
-		} else if errors.Is(err, pgx.ErrTooManyRows) {
-			err = errs.New(adapter.ErrTooManyRows.Error(), err)
-		}
+	dur := time.Since(start)
+	r.release()
 
-		r.tracer.Log(trace.ErrorLevel, "failed to scan a row", map[string]any{
-			trace.ErrorKey: err,
-		})
+	if err != nil {
+		err = translateError(r.opts, r.query, err)
+		r.tracer.Log(failureLog(
+			err,
+			dur,
+			"failed to scan a row",
+			"scanning a row timed out",
+		))
 		return err
 	}
 
@@ -83,36 +116,64 @@ func (r Row) Scan(dest ...any) error {
 type Rows struct {
 	driverRows driver.Rows
 	tracer     trace.Logger
+	opts       *options
+	query      string
+	// release stops tracking the rows for leaks
+	// and releases the connection.
+	release func()
+}
+
+func NewRows(
+	driverRows driver.Rows,
+	tracer trace.Logger,
+	opts ...Option,
+) Rows {
+	return newRows(driverRows, tracer, newOptions(opts), "", func() {})
 }
 
-func NewRows(driverRows driver.Rows, tracer trace.Logger) Rows {
+func newRows(
+	driverRows driver.Rows,
+	tracer trace.Logger,
+	opts *options,
+	query string,
+	release func(),
+) Rows {
+	untrack := opts.leaks.track(tracer, LeakKindRows, query)
 	return Rows{
 		driverRows: driverRows,
 		tracer:     tracer,
+		opts:       opts,
+		query:      query,
+		release: sync.OnceFunc(func() {
+			untrack()
+			release()
+		}),
 	}
 }
 
 func (r Rows) Err() error {
-	return r.driverRows.Err()
+	return translateError(r.opts, r.query, r.driverRows.Err())
 }
 
+// Next closes the rows once they are over.
 func (r Rows) Next() bool {
-	return r.driverRows.Next()
+	if !r.driverRows.Next() {
+		r.release()
+		return false
+	}
+	return true
 }
 
 // Close always returns nil.
 func (r Rows) Close() error {
 	r.driverRows.Close()
+	r.release()
 	return nil
 }
 
 func (r Rows) Scan(dest ...any) error {
-	err := r.driverRows.Scan(dest...)
+	err := translateError(r.opts, r.query, r.driverRows.Scan(dest...))
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			err = errs.New(adapter.ErrNoRows.Error(), err)
-		}
-
 		r.tracer.Log(trace.ErrorLevel, "failed to scan a row", map[string]any{
 			trace.ErrorKey: err,
 		})
@@ -133,36 +194,53 @@ type StmtConn interface {
 }
 
 type Stmt struct {
-	conn   StmtConn
-	tracer trace.Logger
-	ctx    context.Context
-	query  string
+	conn  stmtConn
+	ctx   context.Context
+	query string
 }
 
+// NewStmt returns a statement that runs on the driver connection
+// or transaction as is. The statements prepared by Conn and Tx
+// fail fast once they are closed or done instead.
 func NewStmt(
 	conn StmtConn,
 	tracer trace.Logger,
 	ctx context.Context,
 	query string,
+	opts ...Option,
 ) Stmt {
-	return Stmt{
+	return newStmt(driverStmtConn{
 		conn:   conn,
 		tracer: tracer,
-		ctx:    ctx,
-		query:  query,
+		opts:   newOptions(opts),
+	}, ctx, query)
+}
+
+// stmtConn is a Conn, a Tx or a Pool that runs the statement.
+type stmtConn interface {
+	Exec(ctx context.Context, query string, args ...any) (adapter.Result, error)
+	Query(ctx context.Context, query string, args ...any) (adapter.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...any) adapter.Row
+}
+
+func newStmt(conn stmtConn, ctx context.Context, query string) Stmt {
+	return Stmt{
+		conn:  conn,
+		ctx:   ctx,
+		query: query,
 	}
 }
 
 func (s Stmt) Exec(args ...any) (adapter.Result, error) {
-	return runExec(s.conn, s.tracer, s.ctx, s.query, args...)
+	return s.conn.Exec(s.ctx, s.query, args...)
 }
 
 func (s Stmt) Query(args ...any) (adapter.Rows, error) {
-	return runQuery(s.conn, s.tracer, s.ctx, s.query, args...)
+	return s.conn.Query(s.ctx, s.query, args...)
 }
 
 func (s Stmt) QueryRow(args ...any) adapter.Row {
-	return runQueryRow(s.conn, s.tracer, s.ctx, s.query, args...)
+	return s.conn.QueryRow(s.ctx, s.query, args...)
 }
 
 // Close does nothing and always returns nil.
@@ -170,18 +248,65 @@ func (s Stmt) Close() error {
 	return nil
 }
 
+// driverStmtConn runs the statements of NewStmt on the driver.
+type driverStmtConn struct {
+	conn   StmtConn
+	tracer trace.Logger
+	opts   *options
+}
+
+func (c driverStmtConn) Exec(
+	ctx context.Context,
+	query string,
+	args ...any,
+) (adapter.Result, error) {
+	return runExec(c.conn, c.tracer, c.opts, ctx, query, args...)
+}
+
+func (c driverStmtConn) Query(
+	ctx context.Context,
+	query string,
+	args ...any,
+) (adapter.Rows, error) {
+	return runQuery(c.conn, c.tracer, c.opts, func() {}, ctx, query, args...)
+}
+
+func (c driverStmtConn) QueryRow(
+	ctx context.Context,
+	query string,
+	args ...any,
+) adapter.Row {
+	if c.opts.eagerQueryRow {
+		return runEagerQueryRow(c.conn, c.tracer, c.opts, ctx, query, args...)
+	}
+	return runQueryRow(c.conn, c.tracer, c.opts, func() {}, ctx, query, args...)
+}
+
 // Conn
 // ----
 
 type Conn struct {
 	driverConn driver.Conn
 	tracer     trace.Logger
+	opts       *options
+	state      *connState
+}
+
+type connState struct {
+	closed atomic.Bool
+	// guard is nil unless the concurrency guard is enabled.
+	guard *connGuard
 }
 
-func NewConn(driverConn driver.Conn, tracer trace.Logger) Conn {
+func NewConn(driverConn driver.Conn, tracer trace.Logger, opts ...Option) Conn {
+	o := newOptions(opts)
 	return Conn{
 		driverConn: driverConn,
 		tracer:     tracer,
+		opts:       o,
+		state: &connState{
+			guard: newConnGuard(o.guardMode),
+		},
 	}
 }
 
@@ -190,7 +315,16 @@ func (c Conn) Exec(
 	query string,
 	args ...any,
 ) (adapter.Result, error) {
-	return runExec(c.driverConn, c.tracer, ctx, query, args...)
+	if err := c.checkOpen(query); err != nil {
+		return nil, err
+	}
+	release, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return runExec(c.driverConn, c.tracer, c.opts, ctx, query, args...)
 }
 
 func (c Conn) Query(
@@ -198,7 +332,23 @@ func (c Conn) Query(
 	query string,
 	args ...any,
 ) (adapter.Rows, error) {
-	return runQuery(c.driverConn, c.tracer, ctx, query, args...)
+	if err := c.checkOpen(query); err != nil {
+		return nil, err
+	}
+	release, err := c.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return runQuery(
+		c.driverConn,
+		c.tracer,
+		c.opts,
+		release,
+		ctx,
+		query,
+		args...,
+	)
 }
 
 func (c Conn) QueryRow(
@@ -206,19 +356,92 @@ func (c Conn) QueryRow(
 	query string,
 	args ...any,
 ) adapter.Row {
-	return runQueryRow(c.driverConn, c.tracer, ctx, query, args...)
+	if err := c.checkOpen(query); err != nil {
+		return Row{err: err}
+	}
+	release, err := c.acquire(ctx, query)
+	if err != nil {
+		return Row{err: err}
+	}
+
+	if c.opts.eagerQueryRow {
+		defer release()
+		return runEagerQueryRow(
+			c.driverConn,
+			c.tracer,
+			c.opts,
+			ctx,
+			query,
+			args...,
+		)
+	}
+	return runQueryRow(
+		c.driverConn,
+		c.tracer,
+		c.opts,
+		release,
+		ctx,
+		query,
+		args...,
+	)
 }
 
 func (c Conn) Prepare(ctx context.Context, query string) (adapter.Stmt, error) {
-	return runPrepare(c.driverConn, c.tracer, ctx, query)
+	if err := c.checkOpen(query); err != nil {
+		return nil, err
+	}
+	return runPrepare(c, c.tracer, ctx, query)
 }
 
+// Begin holds the connection until the transaction is done,
+// if the concurrency guard is enabled.
 func (c Conn) Begin(ctx context.Context) (adapter.Tx, error) {
-	return runBegin(c.driverConn, c.tracer, ctx)
+	if err := c.checkOpen(""); err != nil {
+		return nil, err
+	}
+	release, err := c.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return runBegin(c.driverConn, c.tracer, c.opts, nil, release, ctx)
+}
+
+// BeginTx holds the connection until the transaction is done,
+// if the concurrency guard is enabled.
+func (c Conn) BeginTx(
+	ctx context.Context,
+	txOptions adapter.TxOptions,
+) (adapter.Tx, error) {
+	if err := c.checkOpen(""); err != nil {
+		return nil, err
+	}
+	release, err := c.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return runBeginTx(
+		c.driverConn,
+		c.tracer,
+		c.opts,
+		release,
+		ctx,
+		txOptions,
+	)
 }
 
 func (c Conn) Ping(ctx context.Context) error {
-	err := c.driverConn.Ping(ctx)
+	if err := c.checkOpen(""); err != nil {
+		return err
+	}
+	release, err := c.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	err = translateError(c.opts, "", c.driverConn.Ping(ctx))
 	if err != nil {
 		c.tracer.Log(
 			trace.ErrorLevel,
@@ -234,25 +457,273 @@ func (c Conn) Ping(ctx context.Context) error {
 	return nil
 }
 
-// Close always returns nil.
+// Close always returns nil. Closing a closed connection does nothing.
+// If the concurrency guard is enabled, Close waits for the calls,
+// the rows and the transaction that hold the connection.
 func (c Conn) Close() error {
+	if c.state.closed.Swap(true) {
+		return nil
+	}
+
+	c.opts.leaks.report()
+
+	release, _ := c.state.guard.wait(context.Background())
+	defer release()
 	c.driverConn.Close()
 	return nil
 }
 
+// LeakReport returns the transactions and the rows that are still open.
+// It returns nil unless leak detection is enabled. See WithLeakDetection.
+func (c Conn) LeakReport() []Leak {
+	return c.opts.leaks.leaks()
+}
+
+// checkOpen fails fast if the connection is closed.
+func (c Conn) checkOpen(query string) error {
+	if !c.state.closed.Load() {
+		return nil
+	}
+
+	c.tracer.WithCallerSkip(1).
+		Log(trace.ErrorLevel, "used a closed connection", map[string]any{
+			trace.QueryKey: query,
+			trace.ErrorKey: adapter.ErrConnClosed,
+		})
+	return adapter.ErrConnClosed
+}
+
+// acquire waits for the connection, or fails with adapter.ErrConnBusy,
+// if the concurrency guard is enabled. See WithConcurrencyGuard.
+func (c Conn) acquire(ctx context.Context, query string) (func(), error) {
+	release, err := c.state.guard.acquire(ctx)
+	if err != nil {
+		err = translateError(c.opts, query, err)
+		c.tracer.WithCallerSkip(1).Log(
+			trace.ErrorLevel,
+			"failed to acquire the connection",
+			map[string]any{
+				trace.QueryKey: query,
+				trace.ErrorKey: err,
+			},
+		)
+		return nil, err
+	}
+	return release, nil
+}
+
 // Tx
 // --
 
 type Tx struct {
 	driverTx driver.Tx
 	tracer   trace.Logger
+	opts     *options
+	state    *txState
+}
+
+type txState struct {
+	done  atomic.Bool
+	start time.Time
+	// id is shared by the nested transactions,
+	// whose depth is greater than zero.
+	id    string
+	depth int
+	// parent is nil unless the transaction is nested.
+	parent *txState
+	// release stops tracking the transaction for leaks
+	// and releases the connection.
+	release func()
+	// guard is nil unless the concurrency guard is enabled.
+	// Only the outermost transaction has one.
+	guard *connGuard
+
+	mu sync.Mutex
+	// savepoints are the names of the open savepoints,
+	// from the oldest to the most recent.
+	savepoints []string
+	onCommit   []func(ctx context.Context)
+	onRollback []func(ctx context.Context, err error)
+	// snapshots are the ids of the snapshots exported
+	// by the transaction. Only the outermost transaction has them.
+	snapshots []string
+
+	// watchdog is nil unless the transaction has a maximum lifetime
+	// or idle time. Only the outermost transaction has one.
+	watchdog    *time.Timer
+	maxLifetime time.Duration
+	maxIdle     time.Duration
+	// expired is set once the watchdog has rolled the transaction back.
+	expired    atomic.Bool
+	busy       int
+	lastQuery  string
+	lastActive time.Time
+
+	// The statistics of the transaction include the ones
+	// of its nested transactions.
+	queries      int
+	dbTime       time.Duration
+	rowsAffected int64
+}
+
+// isDone reports whether the transaction or any of its parents
+// has been committed or rolled back.
+func (s *txState) isDone() bool {
+	for ; s != nil; s = s.parent {
+		if s.done.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// doneErr returns the error of using the transaction once it is done.
+func (s *txState) doneErr() error {
+	if s.root().expired.Load() {
+		return adapter.ErrTxExpired
+	}
+	return adapter.ErrTxDone
+}
+
+func (s *txState) root() *txState {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+// enter marks the transaction as busy running the query,
+// so that the watchdog waits for it. Every successful call
+// must be followed by a call to leave.
+func (s *txState) enter(query string) error {
+	s = s.root()
+	if s.watchdog == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done.Load() {
+		return s.doneErr()
+	}
+	s.busy++
+	s.lastQuery = query
+	return nil
+}
+
+func (s *txState) leave() {
+	s = s.root()
+	if s.watchdog == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.busy--
+	s.lastActive = time.Now()
+	if s.busy == 0 && !s.done.Load() {
+		s.watchdog.Reset(time.Until(s.expiresAt()))
+	}
+}
+
+// leaveFunc extends release to also leave the statement, for the rows
+// and the lazy row, which are read after the call has returned.
+func (s *txState) leaveFunc(release func()) func() {
+	return func() {
+		s.leave()
+		release()
+	}
+}
+
+// expiresAt must be called with mu held.
+func (s *txState) expiresAt() time.Time {
+	var at time.Time
+	if s.maxLifetime > 0 {
+		at = s.start.Add(s.maxLifetime)
+	}
+	if s.maxIdle > 0 {
+		idleAt := s.lastActive.Add(s.maxIdle)
+		if at.IsZero() || idleAt.Before(at) {
+			at = idleAt
+		}
+	}
+	return at
+}
+
+// finish marks the transaction as done. It returns false
+// if the transaction is already done. Otherwise, release must be called
+// once the transaction has been finished by the driver.
+func (s *txState) finish() bool {
+	if !s.done.CompareAndSwap(false, true) {
+		return false
+	}
+
+	if s.watchdog != nil {
+		s.watchdog.Stop()
+	}
+	s.forgetSnapshots()
+	return true
+}
+
+func NewTx(driverTx driver.Tx, tracer trace.Logger, opts ...Option) Tx {
+	return newTx(driverTx, tracer, newOptions(opts), nil, func() {})
 }
 
-func NewTx(driverTx driver.Tx, tracer trace.Logger) Tx {
-	return Tx{
+// newTx calls releaseConn once the transaction is done.
+func newTx(
+	driverTx driver.Tx,
+	tracer trace.Logger,
+	opts *options,
+	parent *txState,
+	releaseConn func(),
+) Tx {
+	release, guard := releaseConn, (*connGuard)(nil)
+	if parent == nil {
+		untrack := opts.leaks.track(tracer, LeakKindTx, "")
+		release = func() {
+			untrack()
+			releaseConn()
+		}
+		guard = newConnGuard(opts.guardMode)
+	}
+
+	id, depth := newTxID(), 0
+	if parent != nil {
+		id, depth = parent.id, parent.depth+1
+	}
+
+	now := time.Now()
+	tx := Tx{
 		driverTx: driverTx,
-		tracer:   tracer,
+		tracer: tracer.With(map[string]any{
+			trace.TxIDKey:    id,
+			trace.TxDepthKey: depth,
+		}),
+		opts: opts,
+		state: &txState{
+			start:       now,
+			id:          id,
+			depth:       depth,
+			parent:      parent,
+			release:     release,
+			guard:       guard,
+			maxLifetime: opts.txMaxLifetime,
+			maxIdle:     opts.txMaxIdle,
+			lastActive:  now,
+		},
+	}
+
+	if parent == nil && (opts.txMaxLifetime > 0 || opts.txMaxIdle > 0) {
+		tx.state.mu.Lock()
+		tx.state.watchdog = time.AfterFunc(
+			time.Until(tx.state.expiresAt()),
+			tx.watch,
+		)
+		tx.state.mu.Unlock()
 	}
+	return tx
 }
 
 func (t Tx) Exec(
@@ -260,7 +731,29 @@ func (t Tx) Exec(
 	query string,
 	args ...any,
 ) (adapter.Result, error) {
-	return runExec(t.driverTx, t.tracer, ctx, query, args...)
+	if err := t.checkActive(query); err != nil {
+		return nil, err
+	}
+	release, err := t.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err = t.state.enter(query); err != nil {
+		return nil, err
+	}
+	defer t.state.leave()
+
+	start := time.Now()
+	result, err := runExec(t.driverTx, t.tracer, t.opts, ctx, query, args...)
+	if err != nil {
+		t.state.record(time.Since(start), 0)
+		return nil, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	t.state.record(time.Since(start), rowsAffected)
+	return result, nil
 }
 
 func (t Tx) Query(
@@ -268,7 +761,32 @@ func (t Tx) Query(
 	query string,
 	args ...any,
 ) (adapter.Rows, error) {
-	return runQuery(t.driverTx, t.tracer, ctx, query, args...)
+	if err := t.checkActive(query); err != nil {
+		return nil, err
+	}
+	release, err := t.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.state.enter(query); err != nil {
+		release()
+		return nil, err
+	}
+
+	// The rows keep the transaction busy until they are closed,
+	// so that the watchdog does not roll it back while they are read.
+	start := time.Now()
+	rows, err := runQuery(
+		t.driverTx,
+		t.tracer,
+		t.opts,
+		t.state.leaveFunc(release),
+		ctx,
+		query,
+		args...,
+	)
+	t.state.record(time.Since(start), 0)
+	return rows, err
 }
 
 func (t Tx) QueryRow(
@@ -276,57 +794,610 @@ func (t Tx) QueryRow(
 	query string,
 	args ...any,
 ) adapter.Row {
-	return runQueryRow(t.driverTx, t.tracer, ctx, query, args...)
+	if err := t.checkActive(query); err != nil {
+		return Row{err: err}
+	}
+	release, err := t.acquire(ctx, query)
+	if err != nil {
+		return Row{err: err}
+	}
+	if err = t.state.enter(query); err != nil {
+		release()
+		return Row{err: err}
+	}
+
+	start := time.Now()
+	defer func() {
+		t.state.record(time.Since(start), 0)
+	}()
+
+	if t.opts.eagerQueryRow {
+		defer release()
+		defer t.state.leave()
+		return runEagerQueryRow(
+			t.driverTx,
+			t.tracer,
+			t.opts,
+			ctx,
+			query,
+			args...,
+		)
+	}
+	// The row keeps the transaction busy until it is scanned.
+	return runQueryRow(
+		t.driverTx,
+		t.tracer,
+		t.opts,
+		t.state.leaveFunc(release),
+		ctx,
+		query,
+		args...,
+	)
 }
 
 func (t Tx) Prepare(ctx context.Context, query string) (adapter.Stmt, error) {
-	return runPrepare(t.driverTx, t.tracer, ctx, query)
+	if err := t.checkActive(query); err != nil {
+		return nil, err
+	}
+	return runPrepare(t, t.tracer, ctx, query)
 }
 
 func (t Tx) Begin(ctx context.Context) (adapter.Tx, error) {
-	return runBegin(t.driverTx, t.tracer, ctx)
+	if err := t.checkActive(""); err != nil {
+		return nil, err
+	}
+	release, err := t.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err = t.state.enter(""); err != nil {
+		return nil, err
+	}
+	defer t.state.leave()
+
+	return runBegin(t.driverTx, t.tracer, t.opts, t.state, func() {}, ctx)
+}
+
+// BeginTx returns adapter.ErrUnsupportedTxOptions unless txOptions
+// is zero, because a nested transaction is only a savepoint
+// of its parent and shares its options.
+func (t Tx) BeginTx(
+	ctx context.Context,
+	txOptions adapter.TxOptions,
+) (adapter.Tx, error) {
+	if err := t.checkActive(""); err != nil {
+		return nil, err
+	}
+
+	if !isDefaultTxOptions(txOptions) {
+		t.tracer.Log(
+			trace.ErrorLevel,
+			"failed to begin a transaction",
+			map[string]any{
+				trace.ErrorKey:     adapter.ErrUnsupportedTxOptions,
+				trace.TxOptionsKey: redactTxOptions(txOptions),
+			},
+		)
+		return nil, adapter.ErrUnsupportedTxOptions
+	}
+
+	release, err := t.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err = t.state.enter(""); err != nil {
+		return nil, err
+	}
+	defer t.state.leave()
+
+	return runBegin(t.driverTx, t.tracer, t.opts, t.state, func() {}, ctx)
 }
 
 func (t Tx) Commit(ctx context.Context) error {
-	err := t.driverTx.Commit(ctx)
+	if err := t.checkActive(""); err != nil {
+		return err
+	}
+	release, err := t.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+	// The watchdog must not roll back the outermost transaction
+	// while a nested one is released.
+	if err = t.state.enter(""); err != nil {
+		return err
+	}
+	defer t.state.leave()
+	if !t.state.finish() {
+		return t.state.doneErr()
+	}
+
+	err = translateError(t.opts, "", t.driverTx.Commit(ctx))
+	t.state.release()
+	dur := time.Since(t.state.start)
+
 	if err != nil {
 		t.tracer.Log(
 			trace.ErrorLevel,
 			"failed to commit a transaction",
 			map[string]any{
-				trace.ErrorKey: err,
+				trace.ErrorKey:    err,
+				trace.DurationKey: dur,
 			},
 		)
+
+		// The server rolls the transaction back if it fails to commit it,
+		// or if it has been aborted by a failed statement, but the outcome
+		// is unknown if the connection fails.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) || errors.Is(err, adapter.ErrTxRolledBack) {
+			t.runRollbackHooks(ctx, err)
+		}
 		return err
 	}
 
-	t.tracer.Log(trace.TraceLevel, "committed a transaction", nil)
+	t.tracer.Log(trace.TraceLevel, "committed a transaction", t.summary(dur))
+
+	if t.state.parent != nil {
+		t.state.propagateHooks()
+	} else {
+		t.runCommitHooks(ctx)
+	}
 	return nil
 }
 
+// Rollback returns adapter.ErrTxDone if the transaction is already done,
+// which is expected when it is deferred after Commit,
+// or adapter.ErrTxExpired if it has been rolled back by the watchdog.
+//
+// Rollback waits for the connection even if the concurrency guard
+// fails fast, so that the transaction is not left open.
 func (t Tx) Rollback(ctx context.Context) error {
-	err := t.driverTx.Rollback(ctx)
+	release, err := t.state.root().guard.wait(ctx)
 	if err != nil {
+		err = translateError(t.opts, "", err)
 		t.tracer.Log(
 			trace.ErrorLevel,
-			"failed to rollback a transaction",
+			"failed to acquire the connection",
 			map[string]any{
+				trace.QueryKey: "",
 				trace.ErrorKey: err,
 			},
 		)
 		return err
 	}
+	defer release()
+	if err = t.state.enter(""); err == nil {
+		defer t.state.leave()
+	}
+	if err != nil || t.state.isDone() || !t.state.finish() {
+		t.tracer.Log(
+			trace.TraceLevel,
+			"transaction is already done",
+			nil,
+		)
+		return t.state.doneErr()
+	}
 
-	t.tracer.Log(trace.TraceLevel, "rolled back a transaction", nil)
-	return nil
-}
+	err = translateError(t.opts, "", t.driverTx.Rollback(ctx))
+	t.state.release()
+	dur := time.Since(t.state.start)
 
-// Helpers
+	if err != nil {
+		t.tracer.Log(
+			trace.ErrorLevel,
+			"failed to rollback a transaction",
+			map[string]any{
+				trace.ErrorKey:    err,
+				trace.DurationKey: dur,
+			},
+		)
+		return err
+	}
+
+	t.tracer.Log(
+		trace.TraceLevel,
+		"rolled back a transaction",
+		t.summary(dur),
+	)
+
+	t.runRollbackHooks(ctx, nil)
+	return nil
+}
+
+// OnCommit registers fn to be run after the transaction commits.
+// The hooks run in the order they have been registered.
+//
+// The hooks of a nested transaction are passed to its parent
+// when it commits, so they only run when the outermost one commits.
+func (t Tx) OnCommit(fn func(ctx context.Context)) {
+	t.state.mu.Lock()
+	t.state.onCommit = append(t.state.onCommit, fn)
+	t.state.mu.Unlock()
+}
+
+// OnRollback registers fn to be run after the transaction is rolled back,
+// either by Rollback, with a nil error, or by the server because Commit
+// has failed, with the error of Commit.
+// The hooks run in the order they have been registered.
+//
+// The hooks of a nested transaction are passed to its parent
+// when it commits, so they also run if the parent is rolled back.
+func (t Tx) OnRollback(fn func(ctx context.Context, err error)) {
+	t.state.mu.Lock()
+	t.state.onRollback = append(t.state.onRollback, fn)
+	t.state.mu.Unlock()
+}
+
+// propagateHooks passes the hooks of a committed nested transaction
+// to its parent.
+func (s *txState) propagateHooks() {
+	s.mu.Lock()
+	onCommit, onRollback := s.onCommit, s.onRollback
+	s.onCommit, s.onRollback = nil, nil
+	s.mu.Unlock()
+
+	s.parent.mu.Lock()
+	s.parent.onCommit = append(s.parent.onCommit, onCommit...)
+	s.parent.onRollback = append(s.parent.onRollback, onRollback...)
+	s.parent.mu.Unlock()
+}
+
+func (t Tx) runCommitHooks(ctx context.Context) {
+	t.state.mu.Lock()
+	hooks := t.state.onCommit
+	t.state.onCommit, t.state.onRollback = nil, nil
+	t.state.mu.Unlock()
+
+	for _, hook := range hooks {
+		t.runHook(func() { hook(ctx) })
+	}
+}
+
+func (t Tx) runRollbackHooks(ctx context.Context, err error) {
+	t.state.mu.Lock()
+	hooks := t.state.onRollback
+	t.state.onCommit, t.state.onRollback = nil, nil
+	t.state.mu.Unlock()
+
+	for _, hook := range hooks {
+		t.runHook(func() { hook(ctx, err) })
+	}
+}
+
+// runHook logs the panic of the hook instead of propagating it,
+// because the transaction is already done and the rest of the hooks
+// should still run.
+func (t Tx) runHook(hook func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			t.tracer.Log(
+				trace.ErrorLevel,
+				"transaction hook panicked",
+				map[string]any{
+					trace.PanicKey: p,
+				},
+			)
+		}
+	}()
+
+	hook()
+}
+
+func (t Tx) Savepoint(ctx context.Context, name string) error {
+	err := t.runSavepoint(
+		ctx,
+		"SAVEPOINT",
+		name,
+		"created a savepoint",
+		"failed to create a savepoint",
+	)
+	if err != nil {
+		return err
+	}
+
+	t.state.mu.Lock()
+	t.state.savepoints = append(t.state.savepoints, name)
+	t.state.mu.Unlock()
+	return nil
+}
+
+func (t Tx) RollbackTo(ctx context.Context, name string) error {
+	err := t.runSavepoint(
+		ctx,
+		"ROLLBACK TO SAVEPOINT",
+		name,
+		"rolled back to a savepoint",
+		"failed to rollback to a savepoint",
+	)
+	if err != nil {
+		return err
+	}
+
+	t.state.mu.Lock()
+	if i := t.state.lastSavepoint(name); i >= 0 {
+		t.state.savepoints = t.state.savepoints[:i+1]
+	}
+	t.state.mu.Unlock()
+	return nil
+}
+
+func (t Tx) Release(ctx context.Context, name string) error {
+	err := t.runSavepoint(
+		ctx,
+		"RELEASE SAVEPOINT",
+		name,
+		"released a savepoint",
+		"failed to release a savepoint",
+	)
+	if err != nil {
+		return err
+	}
+
+	t.state.mu.Lock()
+	if i := t.state.lastSavepoint(name); i >= 0 {
+		t.state.savepoints = t.state.savepoints[:i]
+	}
+	t.state.mu.Unlock()
+	return nil
+}
+
+// SavepointDepth returns the number of the open savepoints
+// created with Savepoint.
+func (t Tx) SavepointDepth() int {
+	t.state.mu.Lock()
+	defer t.state.mu.Unlock()
+	return len(t.state.savepoints)
+}
+
+// runSavepoint runs the savepoint command, e.g. "SAVEPOINT",
+// with the quoted name.
+func (t Tx) runSavepoint(
+	ctx context.Context,
+	command string,
+	name string,
+	message string,
+	failureMessage string,
+) error {
+
+	if err := t.checkActive(""); err != nil {
+		return err
+	}
+	release, err := t.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err = t.state.enter(""); err != nil {
+		return err
+	}
+	defer t.state.leave()
+
+	tracer := t.tracer.WithCallerSkip(1).With(map[string]any{
+		trace.SavepointKey: name,
+	})
+
+	if !isValidSavepoint(name) {
+		tracer.Log(trace.ErrorLevel, failureMessage, map[string]any{
+			trace.ErrorKey: adapter.ErrInvalidSavepoint,
+		})
+		return adapter.ErrInvalidSavepoint
+	}
+
+	query := command + " " + pgx.Identifier{name}.Sanitize()
+
+	start := time.Now()
+	_, err = t.driverTx.Exec(ctx, query)
+	dur := time.Since(start)
+	t.state.record(dur, 0)
+
+	if err != nil {
+		err = translateError(t.opts, query, err)
+		tracer.Log(trace.ErrorLevel, failureMessage, map[string]any{
+			trace.ErrorKey: err,
+		})
+		return err
+	}
+
+	tracer.Log(trace.TraceLevel, message, map[string]any{
+		trace.DurationKey: dur,
+	})
+	return nil
+}
+
+// lastSavepoint returns the index of the most recent savepoint
+// with the name, or -1 if there is none. It must be called with mu held.
+func (s *txState) lastSavepoint(name string) int {
+	for i := len(s.savepoints) - 1; i >= 0; i-- {
+		if s.savepoints[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isValidSavepoint reports whether the name can be used as an identifier
+// without being truncated by the server.
+func isValidSavepoint(name string) bool {
+	return name != "" &&
+		len(name) <= maxIdentifierLen &&
+		!strings.ContainsRune(name, 0)
+}
+
+// maxIdentifierLen is the maximum length of an identifier in bytes.
+const maxIdentifierLen = 63
+
+// record adds a query to the statistics of the transaction
+// and of its parents.
+func (s *txState) record(dbTime time.Duration, rowsAffected int64) {
+	for ; s != nil; s = s.parent {
+		s.mu.Lock()
+		s.queries++
+		s.dbTime += dbTime
+		s.rowsAffected += rowsAffected
+		s.mu.Unlock()
+	}
+}
+
+// summary returns the trace fields that sum the transaction up.
+func (t Tx) summary(wallTime time.Duration) map[string]any {
+	t.state.mu.Lock()
+	defer t.state.mu.Unlock()
+
+	return map[string]any{
+		trace.DurationKey:     wallTime,
+		trace.QueryCountKey:   t.state.queries,
+		trace.DBTimeKey:       t.state.dbTime,
+		trace.RowsAffectedKey: t.state.rowsAffected,
+	}
+}
+
+// newTxID returns a random ID to tell the transactions apart in traces.
+func newTxID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// checkActive fails fast if the transaction is already done.
+func (t Tx) checkActive(query string) error {
+	if !t.state.isDone() {
+		return nil
+	}
+
+	err := t.state.doneErr()
+	t.tracer.WithCallerSkip(1).
+		Log(trace.ErrorLevel, "used a finished transaction", map[string]any{
+			trace.QueryKey: query,
+			trace.ErrorKey: err,
+		})
+	return err
+}
+
+// acquire waits for the connection, or fails with adapter.ErrConnBusy,
+// if the concurrency guard is enabled. See WithConcurrencyGuard.
+func (t Tx) acquire(ctx context.Context, query string) (func(), error) {
+	release, err := t.state.root().guard.acquire(ctx)
+	if err != nil {
+		err = translateError(t.opts, query, err)
+		t.tracer.WithCallerSkip(1).Log(
+			trace.ErrorLevel,
+			"failed to acquire the connection",
+			map[string]any{
+				trace.QueryKey: query,
+				trace.ErrorKey: err,
+			},
+		)
+		return nil, err
+	}
+	return release, nil
+}
+
+// watchRetryDelay is how soon the watchdog runs again
+// if the connection is in use.
+const watchRetryDelay = 10 * time.Millisecond
+
+// watch rolls the transaction back in the background once it has
+// outlived its maximum lifetime or idle time. It runs on the watchdog.
+func (t Tx) watch() {
+	s := t.state
+
+	// The rows and the calls of the transaction hold the connection.
+	// They reset the watchdog when they leave, but the watchdog does not
+	// wait for them, in case one of them has already left.
+	release, ok := s.guard.tryAcquire()
+	if !ok {
+		s.mu.Lock()
+		if !s.done.Load() {
+			s.watchdog.Reset(watchRetryDelay)
+		}
+		s.mu.Unlock()
+		return
+	}
+	defer release()
+
+	s.mu.Lock()
+	// The watchdog is reset once the transaction is not busy anymore.
+	if s.busy > 0 || s.done.Load() {
+		s.mu.Unlock()
+		return
+	}
+	if wait := time.Until(s.expiresAt()); wait > 0 {
+		s.watchdog.Reset(wait)
+		s.mu.Unlock()
+		return
+	}
+	if !s.done.CompareAndSwap(false, true) {
+		s.mu.Unlock()
+		return
+	}
+	s.expired.Store(true)
+	lastQuery, idle := s.lastQuery, time.Since(s.lastActive)
+	s.mu.Unlock()
+	s.forgetSnapshots()
+
+	err := translateError(
+		t.opts,
+		"",
+		t.driverTx.Rollback(context.Background()),
+	)
+	s.release()
+	fields := map[string]any{
+		trace.QueryKey:    lastQuery,
+		trace.IdleKey:     idle,
+		trace.DurationKey: time.Since(s.start),
+	}
+
+	if err != nil {
+		fields[trace.ErrorKey] = err
+		t.tracer.Log(
+			trace.ErrorLevel,
+			"failed to rollback an expired transaction",
+			fields,
+		)
+		return
+	}
+
+	fields[trace.ErrorKey] = adapter.ErrTxExpired
+	t.tracer.Log(
+		trace.ErrorLevel,
+		"rolled back an expired transaction",
+		fields,
+	)
+
+	t.runRollbackHooks(context.Background(), adapter.ErrTxExpired)
+}
+
+// Helpers
 // -------
 
+// failureLog returns the arguments of Log for the error of a statement.
+// A timeout is logged with the duration, which tells how long
+// the statement has been let run before it was interrupted.
+func failureLog(
+	err error,
+	dur time.Duration,
+	message string,
+	timeoutMessage string,
+) (trace.Level, string, map[string]any) {
+
+	if errors.Is(err, adapter.ErrQueryTimeout) {
+		return trace.ErrorLevel, timeoutMessage, map[string]any{
+			trace.ErrorKey:    err,
+			trace.DurationKey: dur,
+		}
+	}
+	return trace.ErrorLevel, message, map[string]any{
+		trace.ErrorKey: err,
+	}
+}
+
 func runExec(
 	execer driver.Execer,
 	tracer trace.Logger,
+	opts *options,
 	ctx context.Context,
 	query string,
 	args ...any,
@@ -341,23 +1412,13 @@ func runExec(
 	dur := time.Since(start)
 
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case pgerrcode.CheckViolation:
-				err = errs.New(adapter.ErrCheckViolation.Error(), err)
-			case pgerrcode.UniqueViolation:
-				err = errs.New(adapter.ErrUniqueViolation.Error(), err)
-			case pgerrcode.NotNullViolation:
-				err = errs.New(adapter.ErrNotNullViolation.Error(), err)
-			case pgerrcode.ForeignKeyViolation:
-				err = errs.New(adapter.ErrForeignKeyViolation.Error(), err)
-			}
-		}
-
-		tracer.Log(trace.ErrorLevel, "failed to execute", map[string]any{
-			trace.ErrorKey: err,
-		})
+		err = translateError(opts, query, err)
+		tracer.Log(failureLog(
+			err,
+			dur,
+			"failed to execute",
+			"execution timed out",
+		))
 		return nil, err
 	}
 
@@ -370,9 +1431,13 @@ func runExec(
 	return result, nil
 }
 
+// runQuery calls release once the rows are closed,
+// or right away if the query fails.
 func runQuery(
 	querier driver.Querier,
 	tracer trace.Logger,
+	opts *options,
+	release func(),
 	ctx context.Context,
 	query string,
 	args ...any,
@@ -388,9 +1453,14 @@ func runQuery(
 	dur := time.Since(start)
 
 	if err != nil {
-		tracer.Log(trace.ErrorLevel, "failed to execute", map[string]any{
-			trace.ErrorKey: err,
-		})
+		release()
+		err = translateError(opts, query, err)
+		tracer.Log(failureLog(
+			err,
+			dur,
+			"failed to execute",
+			"execution timed out",
+		))
 		return nil, err
 	}
 
@@ -398,13 +1468,16 @@ func runQuery(
 		trace.DurationKey: dur,
 	})
 
-	rows := NewRows(driverRows, tracer)
+	rows := newRows(driverRows, tracer, opts, query, release)
 	return rows, nil
 }
 
+// runQueryRow calls release once the row is scanned.
 func runQueryRow(
 	rowQuerier driver.RowQuerier,
 	tracer trace.Logger,
+	opts *options,
+	release func(),
 	ctx context.Context,
 	query string,
 	args ...any,
@@ -420,13 +1493,114 @@ func runQueryRow(
 		trace.DurationKey: dur,
 	})
 
-	row := NewRow(driverRow, tracer)
+	row := newRow(driverRow, tracer, opts, query, release)
+	return row
+}
+
+// runEagerQueryRow runs the query and reads the first row right away,
+// unlike the driver QueryRow, which defers both until the row is scanned.
+func runEagerQueryRow(
+	querier driver.Querier,
+	tracer trace.Logger,
+	opts *options,
+	ctx context.Context,
+	query string,
+	args ...any,
+) adapter.Row {
+
+	tracer = tracer.WithCallerSkip(1).With(map[string]any{
+		trace.QueryKey: query,
+	})
+
+	start := time.Now()
+	driverRow, err := readRow(querier, ctx, query, args...)
+	dur := time.Since(start)
+
+	if err != nil {
+		err = translateError(opts, query, err)
+		tracer.Log(failureLog(
+			err,
+			dur,
+			"failed to execute",
+			"execution timed out",
+		))
+		return Row{err: err}
+	}
+
+	tracer.Log(trace.TraceLevel, "executed", map[string]any{
+		trace.DurationKey: dur,
+	})
+
+	row := newRow(driverRow, tracer, opts, query, func() {})
 	return row
 }
 
+// readRow reads the first row of the query the way the driver QueryRow
+// does: the rest of the rows are discarded, but the errors they raise
+// are not.
+func readRow(
+	querier driver.Querier,
+	ctx context.Context,
+	query string,
+	args ...any,
+) (driver.Row, error) {
+
+	driverRows, err := querier.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer driverRows.Close()
+
+	if !driverRows.Next() {
+		if err = driverRows.Err(); err != nil {
+			return nil, err
+		}
+		return bufferedRow{err: pgx.ErrNoRows}, nil
+	}
+
+	// The driver reuses the buffers of the row once the rows are closed.
+	values := make([][]byte, 0, len(driverRows.RawValues()))
+	for _, value := range driverRows.RawValues() {
+		values = append(values, bytes.Clone(value))
+	}
+
+	typeMap := pgtype.NewMap()
+	if driverConn := driverRows.Conn(); driverConn != nil {
+		typeMap = driverConn.TypeMap()
+	}
+
+	row := bufferedRow{
+		typeMap: typeMap,
+		fields:  slices.Clone(driverRows.FieldDescriptions()),
+		values:  values,
+	}
+
+	driverRows.Close()
+	if err = driverRows.Err(); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// bufferedRow is a row that has been read before it is scanned.
+type bufferedRow struct {
+	typeMap *pgtype.Map
+	fields  []pgconn.FieldDescription
+	values  [][]byte
+	// err is returned by Scan instead of scanning the row.
+	err error
+}
+
+func (r bufferedRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return pgx.ScanRow(r.typeMap, r.fields, r.values, dest...)
+}
+
 // runPrepare always returns no errors.
 func runPrepare(
-	conn StmtConn,
+	conn stmtConn,
 	tracer trace.Logger,
 	ctx context.Context,
 	query string,
@@ -437,13 +1611,18 @@ func runPrepare(
 			trace.QueryKey: query,
 		})
 
-	stmt := NewStmt(conn, tracer, ctx, query)
+	stmt := newStmt(conn, ctx, query)
 	return stmt, nil
 }
 
+// runBegin calls release once the transaction is done,
+// or right away if it fails to begin.
 func runBegin(
 	beginner driver.Beginner,
 	tracer trace.Logger,
+	opts *options,
+	parent *txState,
+	release func(),
 	ctx context.Context,
 ) (adapter.Tx, error) {
 
@@ -451,6 +1630,8 @@ func runBegin(
 
 	driverTx, err := beginner.Begin(ctx)
 	if err != nil {
+		release()
+		err = translateError(opts, "", err)
 		tracer.Log(
 			trace.ErrorLevel,
 			"failed to begin a transaction",
@@ -461,8 +1642,195 @@ func runBegin(
 		return nil, err
 	}
 
-	tracer.Log(trace.TraceLevel, "began a transaction", nil)
+	tx := newTx(driverTx, tracer, opts, parent, release)
+	tx.tracer.Log(trace.TraceLevel, "began a transaction", nil)
+	return tx, nil
+}
 
-	tx := NewTx(driverTx, tracer)
+// runBeginTx calls release once the transaction is done,
+// or right away if it fails to begin.
+func runBeginTx(
+	txBeginner driver.TxBeginner,
+	tracer trace.Logger,
+	opts *options,
+	release func(),
+	ctx context.Context,
+	txOptions adapter.TxOptions,
+) (adapter.Tx, error) {
+
+	tracer = tracer.WithCallerSkip(1)
+
+	fields := map[string]any{
+		trace.TxOptionsKey: redactTxOptions(txOptions),
+	}
+	if attempt, cause := adapter.TxAttemptFromContext(ctx); attempt > 0 {
+		fields[trace.AttemptKey] = attempt
+		if attempt > 1 {
+			tracer.Log(
+				trace.TraceLevel,
+				"retrying a transaction",
+				map[string]any{
+					trace.AttemptKey: attempt,
+					trace.ErrorKey:   cause,
+				},
+			)
+		}
+	}
+
+	var driverTx pgx.Tx
+	pgxOptions, err := toPgxTxOptions(txOptions)
+	if err == nil {
+		driverTx, err = txBeginner.BeginTx(ctx, pgxOptions)
+		err = translateError(opts, "", err)
+	}
+	if err != nil {
+		release()
+		fields[trace.ErrorKey] = err
+		tracer.Log(trace.ErrorLevel, "failed to begin a transaction", fields)
+		return nil, err
+	}
+
+	tx := newTx(driverTx, tracer, opts, nil, release)
+	if err = tx.configure(ctx, txOptions); err != nil {
+		if tx.state.finish() {
+			_ = driverTx.Rollback(context.WithoutCancel(ctx))
+			tx.state.release()
+		}
+		fields[trace.ErrorKey] = err
+		tx.tracer.Log(
+			trace.ErrorLevel,
+			"failed to begin a transaction",
+			fields,
+		)
+		return nil, err
+	}
+
+	tx.tracer.Log(trace.TraceLevel, "began a transaction", fields)
 	return tx, nil
 }
+
+// configure imports the snapshot and applies the settings
+// of a transaction that has just begun.
+func (t Tx) configure(ctx context.Context, txOptions adapter.TxOptions) error {
+	// The watchdog must not roll the transaction back meanwhile.
+	if err := t.state.enter(""); err != nil {
+		return err
+	}
+	defer t.state.leave()
+
+	if err := t.setSnapshot(ctx, txOptions.Snapshot); err != nil {
+		return err
+	}
+	return t.applySettings(ctx, txOptions)
+}
+
+// toPgxTxOptions returns adapter.ErrUnsupportedTxOptions
+// if the options have unknown values.
+func toPgxTxOptions(txOptions adapter.TxOptions) (pgx.TxOptions, error) {
+	var pgxOptions pgx.TxOptions
+
+	switch txOptions.Isolation {
+	case adapter.LevelDefault:
+	case adapter.LevelReadUncommitted:
+		pgxOptions.IsoLevel = pgx.ReadUncommitted
+	case adapter.LevelReadCommitted:
+		pgxOptions.IsoLevel = pgx.ReadCommitted
+	case adapter.LevelRepeatableRead:
+		pgxOptions.IsoLevel = pgx.RepeatableRead
+	case adapter.LevelSerializable:
+		pgxOptions.IsoLevel = pgx.Serializable
+	default:
+		return pgx.TxOptions{}, adapter.ErrUnsupportedTxOptions
+	}
+
+	switch txOptions.AccessMode {
+	case adapter.AccessModeDefault:
+	case adapter.AccessModeReadWrite:
+		pgxOptions.AccessMode = pgx.ReadWrite
+	case adapter.AccessModeReadOnly:
+		pgxOptions.AccessMode = pgx.ReadOnly
+	default:
+		return pgx.TxOptions{}, adapter.ErrUnsupportedTxOptions
+	}
+
+	// Only the transactions that keep their first snapshot
+	// can import one.
+	if txOptions.Snapshot != "" {
+		if !isValidSnapshot(txOptions.Snapshot) {
+			return pgx.TxOptions{}, adapter.ErrSnapshotInvalid
+		}
+
+		switch pgxOptions.IsoLevel {
+		case "":
+			pgxOptions.IsoLevel = pgx.RepeatableRead
+		case pgx.ReadUncommitted, pgx.ReadCommitted:
+			return pgx.TxOptions{}, adapter.ErrUnsupportedTxOptions
+		}
+	}
+
+	if txOptions.Deferrable {
+		pgxOptions.DeferrableMode = pgx.Deferrable
+	}
+	return pgxOptions, nil
+}
+
+// applySettingsQuery sets the run-time parameters in a single round trip.
+const applySettingsQuery = `SELECT set_config(name, value, true)
+FROM unnest($1::text[], $2::text[]) AS settings(name, value)`
+
+// applySettings sets the settings and the role of the transaction
+// locally, so that they are reset once it is done.
+func (t Tx) applySettings(
+	ctx context.Context,
+	txOptions adapter.TxOptions,
+) error {
+
+	if len(txOptions.Settings) == 0 && txOptions.Role == "" {
+		return nil
+	}
+
+	// The order is fixed to make the failures reproducible.
+	names := slices.Sorted(maps.Keys(txOptions.Settings))
+	values := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		values = append(values, txOptions.Settings[name])
+	}
+	if txOptions.Role != "" {
+		names = append(names, "role")
+		values = append(values, txOptions.Role)
+	}
+
+	start := time.Now()
+	_, err := t.driverTx.Exec(ctx, applySettingsQuery, names, values)
+	t.state.record(time.Since(start), 0)
+	return translateError(t.opts, applySettingsQuery, err)
+}
+
+// isDefaultTxOptions reports whether the options are all zero.
+func isDefaultTxOptions(txOptions adapter.TxOptions) bool {
+	return txOptions.Isolation == adapter.LevelDefault &&
+		txOptions.AccessMode == adapter.AccessModeDefault &&
+		!txOptions.Deferrable &&
+		len(txOptions.Settings) == 0 &&
+		txOptions.Role == "" &&
+		txOptions.Snapshot == ""
+}
+
+// redactedValue replaces the values of the settings in traces,
+// since they may identify the users.
+const redactedValue = "[REDACTED]"
+
+// redactTxOptions returns a copy of the options
+// whose settings are safe to trace.
+func redactTxOptions(txOptions adapter.TxOptions) adapter.TxOptions {
+	if len(txOptions.Settings) == 0 {
+		return txOptions
+	}
+
+	settings := make(map[string]string, len(txOptions.Settings))
+	for name := range txOptions.Settings {
+		settings[name] = redactedValue
+	}
+	txOptions.Settings = settings
+	return txOptions
+}
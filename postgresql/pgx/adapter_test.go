@@ -3,7 +3,10 @@ package pgxadapt
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	adapter "github.com/adanyl0v/go-sql-adapter"
 	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver"
@@ -13,6 +16,7 @@ import (
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -128,6 +132,8 @@ func TestRow_Scan(t *testing.T) {
 			Command: command,
 			Check: func(err error) {
 				require.EqualError(t, err, adapter.ErrNoRows.Error())
+				require.ErrorIs(t, err, adapter.ErrNoRows)
+				require.ErrorIs(t, err, pgx.ErrNoRows)
 			},
 		},
 		"too_many_rows": {
@@ -144,6 +150,41 @@ func TestRow_Scan(t *testing.T) {
 			Command: command,
 			Check: func(err error) {
 				require.EqualError(t, err, adapter.ErrTooManyRows.Error())
+				require.ErrorIs(t, err, adapter.ErrTooManyRows)
+				require.ErrorIs(t, err, pgx.ErrTooManyRows)
+			},
+		},
+		"timeout": {
+			Expect: func(mockRow *mock_driver.MockRow, mockTracer *mock_trace.MockLogger) {
+				mockRow.
+					EXPECT().
+					Scan(nil).
+					Return(context.DeadlineExceeded)
+
+				mockTracer.
+					EXPECT().
+					Log(trace.ErrorLevel, "scanning a row timed out", gomock.Any())
+			},
+			Command: command,
+			Check: func(err error) {
+				require.ErrorIs(t, err, adapter.ErrQueryTimeout)
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+		"unique_violation": {
+			Expect: func(mockRow *mock_driver.MockRow, mockTracer *mock_trace.MockLogger) {
+				mockRow.
+					EXPECT().
+					Scan(nil).
+					Return(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+
+				mockTracer.
+					EXPECT().
+					Log(trace.ErrorLevel, "failed to scan a row", gomock.Any())
+			},
+			Command: command,
+			Check: func(err error) {
+				require.ErrorIs(t, err, adapter.ErrUniqueViolation)
 			},
 		},
 	}
@@ -276,6 +317,8 @@ func TestRows_Scan(t *testing.T) {
 
 		err := rows.Scan(nil)
 		require.EqualError(t, err, adapter.ErrNoRows.Error())
+		require.ErrorIs(t, err, adapter.ErrNoRows)
+		require.ErrorIs(t, err, pgx.ErrNoRows)
 	})
 }
 
@@ -283,6 +326,70 @@ func TestRows_Scan(t *testing.T) {
 // ----
 
 func TestStmt_Exec(t *testing.T) {
+	t.Parallel()
+
+	t.Run("closed_conn", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Close()
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			WithCallerSkip(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "prepared a statement", gomock.Any())
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "used a closed connection", map[string]any{
+				trace.QueryKey: "DELETE FROM users",
+				trace.ErrorKey: adapter.ErrConnClosed,
+			})
+
+		conn := NewConn(mockConn, mockTracer)
+
+		stmt, err := conn.Prepare(context.Background(), "DELETE FROM users")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		_, err = stmt.Exec()
+		require.ErrorIs(t, err, adapter.ErrConnClosed)
+	})
+
+	t.Run("driver_conn", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Exec(gomock.Any(), "DELETE FROM users", 1).
+			Return(pgconn.NewCommandTag("DELETE 1"), nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "executed", gomock.Any())
+
+		stmt := NewStmt(
+			mockConn,
+			mockTracer,
+			context.Background(),
+			"DELETE FROM users",
+		)
+
+		result, err := stmt.Exec(1)
+		require.NoError(t, err)
+
+		rowsAffected, err := result.RowsAffected()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rowsAffected)
+	})
 }
 
 func TestStmt_Query(t *testing.T) {
@@ -302,6 +409,42 @@ func TestStmt_Close(t *testing.T) {
 // ----
 
 func TestConn_Exec(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error_translator", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		errCustom := errors.New("custom")
+		const query = "SELECT raise_custom()"
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Exec(gomock.Any(), query).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{Code: "U0001"})
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			WithCallerSkip(gomock.Any()).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "failed to execute", gomock.Any())
+
+		conn := NewConn(mockConn, mockTracer, WithErrorTranslator(
+			ErrorTranslatorFunc(func(string, *pgconn.PgError) error {
+				return errCustom
+			}),
+		))
+
+		_, err := conn.Exec(context.Background(), query)
+		require.ErrorIs(t, err, errCustom)
+	})
 }
 
 func TestConn_Query(t *testing.T) {
@@ -316,6 +459,41 @@ func TestConn_Prepare(t *testing.T) {
 func TestConn_Begin(t *testing.T) {
 }
 
+func TestConn_Close(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockConn := mock_driver.NewMockConn(ctrl)
+	mockConn.
+		EXPECT().
+		Close()
+
+	mockTracer := mock_trace.NewMockLogger(ctrl)
+	mockTracer.
+		EXPECT().
+		WithCallerSkip(gomock.Any()).
+		Return(mockTracer).
+		AnyTimes()
+	mockTracer.
+		EXPECT().
+		Log(trace.ErrorLevel, "used a closed connection", gomock.Any()).
+		Times(3)
+
+	conn := NewConn(mockConn, mockTracer)
+	require.NoError(t, conn.Close())
+	require.NoError(t, conn.Close())
+
+	_, err := conn.Query(context.Background(), "SELECT 1")
+	require.ErrorIs(t, err, adapter.ErrConnClosed)
+
+	row := conn.QueryRow(context.Background(), "SELECT 1")
+	require.ErrorIs(t, row.Err(), adapter.ErrConnClosed)
+	require.ErrorIs(t, row.Scan(), adapter.ErrConnClosed)
+
+	_, err = conn.Begin(context.Background())
+	require.ErrorIs(t, err, adapter.ErrConnClosed)
+}
+
 func TestConn_Ping(t *testing.T) {
 	t.Parallel()
 
@@ -376,49 +554,273 @@ func TestTx_Prepare(t *testing.T) {
 func TestTx_Begin(t *testing.T) {
 }
 
-func TestTx_Commit(t *testing.T) {
+func TestTx_BeginTx(t *testing.T) {
 	t.Parallel()
+	ctrl := gomock.NewController(t)
 
-	t.Run("success", func(t *testing.T) {
+	mockTx := mock_driver.NewMockTx(ctrl)
+	mockTx.
+		EXPECT().
+		Begin(gomock.Any()).
+		Return(nil, nil)
+
+	mockTracer := mock_trace.NewMockLogger(ctrl)
+	mockTracer.
+		EXPECT().
+		With(gomock.Any()).
+		Return(mockTracer).
+		AnyTimes()
+	mockTracer.
+		EXPECT().
+		WithCallerSkip(gomock.Any()).
+		Return(mockTracer)
+	mockTracer.
+		EXPECT().
+		Log(trace.ErrorLevel, "failed to begin a transaction", gomock.Any())
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "began a transaction", gomock.Any())
+
+	ctx := context.Background()
+	tx := NewTx(mockTx, mockTracer)
+
+	_, err := tx.BeginTx(ctx, adapter.TxOptions{
+		Isolation: adapter.LevelSerializable,
+	})
+	require.ErrorIs(t, err, adapter.ErrUnsupportedTxOptions)
+
+	_, err = tx.BeginTx(ctx, adapter.TxOptions{})
+	require.NoError(t, err)
+}
+
+func TestTx_Savepoint(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockTx := mock_driver.NewMockTx(ctrl)
+	gomock.InOrder(
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `SAVEPOINT "a"`),
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `SAVEPOINT "b"`),
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `SAVEPOINT "Row ""1"""`),
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `ROLLBACK TO SAVEPOINT "b"`),
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `RELEASE SAVEPOINT "a"`),
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `RELEASE SAVEPOINT "a"`).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{
+				Code: pgerrcode.InvalidSavepointSpecification,
+			}),
+	)
+
+	mockTracer := newPermissiveTracer(ctrl)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "created a savepoint", gomock.Any()).
+		Times(3)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "rolled back to a savepoint", gomock.Any())
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "released a savepoint", gomock.Any())
+	mockTracer.
+		EXPECT().
+		Log(trace.ErrorLevel, "failed to create a savepoint", gomock.Any()).
+		Times(2)
+	mockTracer.
+		EXPECT().
+		Log(trace.ErrorLevel, "failed to release a savepoint", gomock.Any())
+
+	ctx := context.Background()
+	tx := NewTx(mockTx, mockTracer)
+
+	require.NoError(t, tx.Savepoint(ctx, "a"))
+	require.NoError(t, tx.Savepoint(ctx, "b"))
+	require.NoError(t, tx.Savepoint(ctx, `Row "1"`))
+	require.Equal(t, 3, tx.SavepointDepth())
+
+	require.NoError(t, tx.RollbackTo(ctx, "b"))
+	require.Equal(t, 2, tx.SavepointDepth())
+
+	require.NoError(t, tx.Release(ctx, "a"))
+	require.Equal(t, 0, tx.SavepointDepth())
+
+	err := tx.Release(ctx, "a")
+	require.ErrorIs(t, err, adapter.ErrUnknownSavepoint)
+
+	err = tx.Savepoint(ctx, "")
+	require.ErrorIs(t, err, adapter.ErrInvalidSavepoint)
+	err = tx.Savepoint(ctx, strings.Repeat("a", 64))
+	require.ErrorIs(t, err, adapter.ErrInvalidSavepoint)
+	require.Equal(t, 0, tx.SavepointDepth())
+
+	// The statements that have been sent are counted.
+	require.Equal(t, 6, tx.state.queries)
+}
+
+func TestTx_Hooks(t *testing.T) {
+	t.Parallel()
+
+	newTracer := func(ctrl *gomock.Controller) *mock_trace.MockLogger {
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(
+				gomock.Any(),
+				gomock.Not("transaction hook panicked"),
+				gomock.Any(),
+			).
+			AnyTimes()
+		return mockTracer
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		t.Parallel()
 		ctrl := gomock.NewController(t)
 
+		mockNestedTx := mock_driver.NewMockTx(ctrl)
+		mockNestedTx.
+			EXPECT().
+			Commit(gomock.Any()).
+			Return(nil)
+
 		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{driverTx: mockNestedTx}, nil)
 		mockTx.
 			EXPECT().
 			Commit(gomock.Any()).
 			Return(nil)
 
-		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer := newTracer(ctrl)
 		mockTracer.
 			EXPECT().
-			Log(trace.TraceLevel, "committed a transaction", gomock.Any())
+			Log(trace.ErrorLevel, "transaction hook panicked", map[string]any{
+				trace.PanicKey: "hook",
+			})
 
+		ctx := context.Background()
 		tx := NewTx(mockTx, mockTracer)
-		err := tx.Commit(context.Background())
+
+		var calls []string
+		tx.OnCommit(func(context.Context) {
+			calls = append(calls, "outer")
+		})
+		tx.OnRollback(func(context.Context, error) {
+			calls = append(calls, "outer rollback")
+		})
+
+		nested, err := tx.Begin(ctx)
 		require.NoError(t, err)
+
+		nestedTx := nested.(Tx)
+		nestedTx.OnCommit(func(context.Context) {
+			panic("hook")
+		})
+		nestedTx.OnCommit(func(context.Context) {
+			calls = append(calls, "nested")
+		})
+
+		require.NoError(t, nestedTx.Commit(ctx))
+		require.Empty(t, calls)
+
+		require.NoError(t, tx.Commit(ctx))
+		require.Equal(t, []string{"outer", "nested"}, calls)
 	})
 
-	t.Run("failure", func(t *testing.T) {
+	t.Run("rollback", func(t *testing.T) {
+		t.Parallel()
 		ctrl := gomock.NewController(t)
 
+		mockNestedTx := mock_driver.NewMockTx(ctrl)
+		mockNestedTx.
+			EXPECT().
+			Rollback(gomock.Any()).
+			Return(nil)
+
 		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{driverTx: mockNestedTx}, nil)
 		mockTx.
 			EXPECT().
 			Commit(gomock.Any()).
-			Return(errors.New(""))
+			Return(&pgconn.PgError{Code: pgerrcode.SerializationFailure})
 
-		mockTracer := mock_trace.NewMockLogger(ctrl)
-		mockTracer.
+		ctx := context.Background()
+		tx := NewTx(mockTx, newTracer(ctrl))
+
+		var errs []error
+		tx.OnCommit(func(context.Context) {
+			t.Fatal("commit hook must not run")
+		})
+		tx.OnRollback(func(_ context.Context, err error) {
+			errs = append(errs, err)
+		})
+
+		nested, err := tx.Begin(ctx)
+		require.NoError(t, err)
+
+		nestedTx := nested.(Tx)
+		nestedTx.OnRollback(func(_ context.Context, err error) {
+			errs = append(errs, err)
+		})
+
+		require.NoError(t, nestedTx.Rollback(ctx))
+		require.Equal(t, []error{nil}, errs)
+
+		err = tx.Commit(ctx)
+		require.ErrorIs(t, err, adapter.ErrSerializationFailure)
+		require.Len(t, errs, 2)
+		require.ErrorIs(t, errs[1], adapter.ErrSerializationFailure)
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		// pgx commits an aborted transaction by rolling it back.
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
 			EXPECT().
-			Log(trace.ErrorLevel, "failed to commit a transaction", gomock.Any())
+			Commit(gomock.Any()).
+			Return(pgx.ErrTxCommitRollback)
 
-		tx := NewTx(mockTx, mockTracer)
-		err := tx.Commit(context.Background())
-		require.Error(t, err)
+		ctx := context.Background()
+		tx := NewTx(mockTx, newTracer(ctrl))
+
+		var errs []error
+		tx.OnCommit(func(context.Context) {
+			t.Fatal("commit hook must not run")
+		})
+		tx.OnRollback(func(_ context.Context, err error) {
+			errs = append(errs, err)
+		})
+
+		err := tx.Commit(ctx)
+		require.ErrorIs(t, err, adapter.ErrTxRolledBack)
+		require.Len(t, errs, 1)
+		require.ErrorIs(t, errs[0], adapter.ErrTxRolledBack)
+
+		require.ErrorIs(t, tx.Rollback(ctx), adapter.ErrTxDone)
+		require.Len(t, errs, 1)
 	})
 }
 
-func TestTx_Rollback(t *testing.T) {
+func TestTx_Commit(t *testing.T) {
 	t.Parallel()
 
 	t.Run("success", func(t *testing.T) {
@@ -427,16 +829,21 @@ func TestTx_Rollback(t *testing.T) {
 		mockTx := mock_driver.NewMockTx(ctrl)
 		mockTx.
 			EXPECT().
-			Rollback(gomock.Any()).
+			Commit(gomock.Any()).
 			Return(nil)
 
 		mockTracer := mock_trace.NewMockLogger(ctrl)
 		mockTracer.
 			EXPECT().
-			Log(trace.TraceLevel, "rolled back a transaction", gomock.Any())
+			With(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "committed a transaction", gomock.Any())
 
 		tx := NewTx(mockTx, mockTracer)
-		err := tx.Rollback(context.Background())
+		err := tx.Commit(context.Background())
 		require.NoError(t, err)
 	})
 
@@ -446,35 +853,154 @@ func TestTx_Rollback(t *testing.T) {
 		mockTx := mock_driver.NewMockTx(ctrl)
 		mockTx.
 			EXPECT().
-			Rollback(gomock.Any()).
+			Commit(gomock.Any()).
 			Return(errors.New(""))
 
 		mockTracer := mock_trace.NewMockLogger(ctrl)
 		mockTracer.
 			EXPECT().
-			Log(trace.ErrorLevel, "failed to rollback a transaction", gomock.Any())
+			With(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "failed to commit a transaction", gomock.Any())
 
 		tx := NewTx(mockTx, mockTracer)
-		err := tx.Rollback(context.Background())
+		err := tx.Commit(context.Background())
 		require.Error(t, err)
 	})
-}
-
-// Helpers
-// -------
 
-// ATTENTION!!!
-// This is the creepiest part of the package...
+	t.Run("deferred_violation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
 
-func TestRunExec(t *testing.T) {
-	t.Parallel()
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any()).
+			Return(&pgconn.PgError{Code: pgerrcode.ForeignKeyViolation})
 
-	type commandType func(
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "failed to commit a transaction", gomock.Any())
+
+		tx := NewTx(mockTx, mockTracer)
+		err := tx.Commit(context.Background())
+		require.ErrorIs(t, err, adapter.ErrForeignKeyViolation)
+	})
+}
+
+func TestTx_Done(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockTx := mock_driver.NewMockTx(ctrl)
+	mockTx.
+		EXPECT().
+		Commit(gomock.Any()).
+		Return(nil)
+
+	mockTracer := newPermissiveTracer(ctrl)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "committed a transaction", gomock.Any())
+	mockTracer.
+		EXPECT().
+		Log(trace.ErrorLevel, "used a finished transaction", map[string]any{
+			trace.QueryKey: "UPDATE users SET name = $1",
+			trace.ErrorKey: adapter.ErrTxDone,
+		})
+	mockTracer.
+		EXPECT().
+		Log(trace.ErrorLevel, "used a finished transaction", gomock.Any())
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "transaction is already done", nil)
+
+	ctx := context.Background()
+	tx := NewTx(mockTx, mockTracer)
+	require.NoError(t, tx.Commit(ctx))
+
+	_, err := tx.Exec(ctx, "UPDATE users SET name = $1", "")
+	require.ErrorIs(t, err, adapter.ErrTxDone)
+	require.ErrorIs(t, tx.Commit(ctx), adapter.ErrTxDone)
+	require.ErrorIs(t, tx.Rollback(ctx), adapter.ErrTxDone)
+}
+
+func TestTx_Rollback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Rollback(gomock.Any()).
+			Return(nil)
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "rolled back a transaction", gomock.Any())
+
+		tx := NewTx(mockTx, mockTracer)
+		err := tx.Rollback(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Rollback(gomock.Any()).
+			Return(errors.New(""))
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "failed to rollback a transaction", gomock.Any())
+
+		tx := NewTx(mockTx, mockTracer)
+		err := tx.Rollback(context.Background())
+		require.Error(t, err)
+	})
+}
+
+// Helpers
+// -------
+
+// ATTENTION!!!
+// This is the creepiest part of the package...
+
+func TestRunExec(t *testing.T) {
+	t.Parallel()
+
+	type commandType func(
 		execer driver.Execer,
 		tracer trace.Logger,
 		runExecFn func(
 			execer driver.Execer,
 			tracer trace.Logger,
+			opts *options,
 			ctx context.Context,
 			query string,
 			args ...any,
@@ -487,12 +1013,19 @@ func TestRunExec(t *testing.T) {
 		runExecFn func(
 			execer driver.Execer,
 			tracer trace.Logger,
+			opts *options,
 			ctx context.Context,
 			query string,
 			args ...any,
 		) (adapter.Result, error),
 	) (adapter.Result, error) {
-		return runExecFn(execer, tracer, context.Background(), "")
+		return runExecFn(
+			execer,
+			tracer,
+			newOptions(nil),
+			context.Background(),
+			"",
+		)
 	}
 
 	testCases := map[string]struct {
@@ -544,6 +1077,57 @@ func TestRunExec(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		"timeout": {
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockExecer *mock_driver.MockExecer,
+				mockTracer *mock_trace.MockLogger,
+			) {
+				mockExecer.
+					EXPECT().
+					Exec(gomock.Any(), "").
+					Return(pgconn.CommandTag{}, context.DeadlineExceeded)
+
+				mockTracer.
+					EXPECT().
+					Log(
+						trace.ErrorLevel,
+						"execution timed out",
+						gomock.Cond(func(fields map[string]any) bool {
+							_, ok := fields[trace.DurationKey]
+							return ok
+						}),
+					)
+			},
+			Command: command,
+			Check: func(_ adapter.Result, err error) {
+				require.ErrorIs(t, err, adapter.ErrQueryTimeout)
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				require.True(t, adapter.IsTimeout(err))
+			},
+		},
+		"canceled": {
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockExecer *mock_driver.MockExecer,
+				mockTracer *mock_trace.MockLogger,
+			) {
+				mockExecer.
+					EXPECT().
+					Exec(gomock.Any(), "").
+					Return(pgconn.CommandTag{}, context.Canceled)
+
+				mockTracer.
+					EXPECT().
+					Log(trace.ErrorLevel, "failed to execute", gomock.Any())
+			},
+			Command: command,
+			Check: func(_ adapter.Result, err error) {
+				require.ErrorIs(t, err, adapter.ErrQueryCanceled)
+				require.ErrorIs(t, err, context.Canceled)
+				require.False(t, adapter.IsTimeout(err))
+			},
+		},
 		"check_violation": {
 			Expect: func(
 				ctrl *gomock.Controller,
@@ -564,6 +1148,10 @@ func TestRunExec(t *testing.T) {
 			Command: command,
 			Check: func(_ adapter.Result, err error) {
 				require.EqualError(t, err, adapter.ErrCheckViolation.Error())
+				require.ErrorIs(t, err, adapter.ErrCheckViolation)
+
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
 			},
 		},
 		"unique_violation": {
@@ -586,6 +1174,52 @@ func TestRunExec(t *testing.T) {
 			Command: command,
 			Check: func(_ adapter.Result, err error) {
 				require.EqualError(t, err, adapter.ErrUniqueViolation.Error())
+				require.ErrorIs(t, err, adapter.ErrUniqueViolation)
+
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
+			},
+		},
+		"constraint_details": {
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockExecer *mock_driver.MockExecer,
+				mockTracer *mock_trace.MockLogger,
+			) {
+				mockExecer.
+					EXPECT().
+					Exec(gomock.Any(), "").
+					Return(pgconn.CommandTag{}, &pgconn.PgError{
+						Code:           pgerrcode.UniqueViolation,
+						ConstraintName: "users_email_key",
+						TableName:      "users",
+						ColumnName:     "email",
+						Detail:         "Key (email)=(a@b.c) already exists.",
+					})
+
+				mockTracer.
+					EXPECT().
+					Log(trace.ErrorLevel, "failed to execute", gomock.Any())
+			},
+			Command: command,
+			Check: func(_ adapter.Result, err error) {
+				require.EqualError(
+					t,
+					err,
+					`violated the unique constraint "users_email_key"`,
+				)
+				require.ErrorIs(t, err, adapter.ErrUniqueViolation)
+
+				var constraintErr *adapter.ConstraintError
+				require.ErrorAs(t, err, &constraintErr)
+				require.Equal(t, &adapter.ConstraintError{
+					Err:        adapter.ErrUniqueViolation,
+					Code:       pgerrcode.UniqueViolation,
+					Constraint: "users_email_key",
+					Table:      "users",
+					Column:     "email",
+					Detail:     "Key (email)=(a@b.c) already exists.",
+				}, constraintErr)
 			},
 		},
 		"not_null_violation": {
@@ -608,6 +1242,10 @@ func TestRunExec(t *testing.T) {
 			Command: command,
 			Check: func(_ adapter.Result, err error) {
 				require.EqualError(t, err, adapter.ErrNotNullViolation.Error())
+				require.ErrorIs(t, err, adapter.ErrNotNullViolation)
+
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
 			},
 		},
 		"foreign_key_violation": {
@@ -634,6 +1272,10 @@ func TestRunExec(t *testing.T) {
 					err,
 					adapter.ErrForeignKeyViolation.Error(),
 				)
+				require.ErrorIs(t, err, adapter.ErrForeignKeyViolation)
+
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
 			},
 		},
 	}
@@ -670,6 +1312,8 @@ func TestRunQuery(t *testing.T) {
 		runExecFn func(
 			querier driver.Querier,
 			tracer trace.Logger,
+			opts *options,
+			release func(),
 			ctx context.Context,
 			query string,
 			args ...any,
@@ -682,12 +1326,21 @@ func TestRunQuery(t *testing.T) {
 		runQueryFn func(
 			querier driver.Querier,
 			tracer trace.Logger,
+			opts *options,
+			release func(),
 			ctx context.Context,
 			query string,
 			args ...any,
 		) (rows adapter.Rows, err error),
 	) (rows adapter.Rows, err error) {
-		return runQueryFn(querier, tracer, context.Background(), "")
+		return runQueryFn(
+			querier,
+			tracer,
+			newOptions(nil),
+			func() {},
+			context.Background(),
+			"",
+		)
 	}
 
 	testCases := map[string]struct {
@@ -739,6 +1392,81 @@ func TestRunQuery(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		"statement_canceled": {
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockQuerier *mock_driver.MockQuerier,
+				mockTracer *mock_trace.MockLogger,
+			) {
+				mockQuerier.
+					EXPECT().
+					Query(gomock.Any(), "").
+					Return(nil, &pgconn.PgError{
+						Code: pgerrcode.QueryCanceled,
+					})
+
+				mockTracer.
+					EXPECT().
+					Log(trace.ErrorLevel, "failed to execute", gomock.Any())
+			},
+			Command: command,
+			Check: func(_ adapter.Rows, err error) {
+				require.ErrorIs(t, err, adapter.ErrQueryCanceled)
+			},
+		},
+		"statement_timeout": {
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockQuerier *mock_driver.MockQuerier,
+				mockTracer *mock_trace.MockLogger,
+			) {
+				mockQuerier.
+					EXPECT().
+					Query(gomock.Any(), "").
+					Return(nil, &pgconn.PgError{
+						Code:    pgerrcode.QueryCanceled,
+						Message: "canceling statement due to statement timeout",
+					})
+
+				mockTracer.
+					EXPECT().
+					Log(
+						trace.ErrorLevel,
+						"execution timed out",
+						gomock.Cond(func(fields map[string]any) bool {
+							_, ok := fields[trace.DurationKey]
+							return ok
+						}),
+					)
+			},
+			Command: command,
+			Check: func(_ adapter.Rows, err error) {
+				require.ErrorIs(t, err, adapter.ErrQueryTimeout)
+				require.True(t, adapter.IsTimeout(err))
+			},
+		},
+		"unique_violation": {
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockQuerier *mock_driver.MockQuerier,
+				mockTracer *mock_trace.MockLogger,
+			) {
+				mockQuerier.
+					EXPECT().
+					Query(gomock.Any(), "").
+					Return(nil, &pgconn.PgError{
+						Code: pgerrcode.UniqueViolation,
+					})
+
+				mockTracer.
+					EXPECT().
+					Log(trace.ErrorLevel, "failed to execute", gomock.Any())
+			},
+			Command: command,
+			Check: func(_ adapter.Rows, err error) {
+				require.ErrorIs(t, err, adapter.ErrUniqueViolation)
+			},
+		},
 	}
 
 	for name, testCase := range testCases {
@@ -783,64 +1511,235 @@ func TestRunQueryRow(t *testing.T) {
 		EXPECT().
 		QueryRow(gomock.Any(), "")
 
-	_ = runQueryRow(mockRowQuerier, mockTracer, context.Background(), "")
+	_ = runQueryRow(
+		mockRowQuerier,
+		mockTracer,
+		newOptions(nil),
+		func() {},
+		context.Background(),
+		"",
+	)
 }
 
-func TestRunPrepare(t *testing.T) {
-	t.Parallel()
-	ctrl := gomock.NewController(t)
-
-	mockConn := mock_driver.NewMockConn(ctrl)
-
-	mockTracer := mock_trace.NewMockLogger(ctrl)
-	mockTracer.
-		EXPECT().
-		WithCallerSkip(gomock.Any()).
-		Return(mockTracer)
-	mockTracer.
-		EXPECT().
-		Log(trace.TraceLevel, "prepared a statement", gomock.Any())
-
-	_, err := runPrepare(mockConn, mockTracer, context.Background(), "")
-	require.NoError(t, err)
+// fakeRows is the result of a query that returns at most one row.
+type fakeRows struct {
+	pgx.Rows
+	values [][]byte
+	err    error
+	read   bool
 }
 
-func TestRunBegin(t *testing.T) {
-	t.Parallel()
+func (r *fakeRows) Next() bool {
+	if r.read || r.values == nil {
+		return false
+	}
+	r.read = true
+	return true
+}
 
-	t.Run("success", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
+func (r *fakeRows) Close() {}
 
-		mockBeginner := mock_driver.NewMockBeginner(ctrl)
-		mockBeginner.
-			EXPECT().
-			Begin(gomock.Any()).
-			Return(nil, nil)
+func (r *fakeRows) Err() error {
+	return r.err
+}
 
-		mockTracer := mock_trace.NewMockLogger(ctrl)
-		mockTracer.
-			EXPECT().
-			WithCallerSkip(gomock.Any()).
-			Return(mockTracer)
-		mockTracer.
-			EXPECT().
-			Log(trace.TraceLevel, "began a transaction", gomock.Any())
+func (r *fakeRows) Conn() *pgx.Conn {
+	return nil
+}
 
-		_, err := runBegin(mockBeginner, mockTracer, context.Background())
-		require.NoError(t, err)
-	})
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	return []pgconn.FieldDescription{
+		{
+			Name:        "id",
+			DataTypeOID: pgtype.Int4OID,
+			Format:      pgtype.TextFormatCode,
+		},
+	}
+}
 
-	t.Run("failure", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
+func (r *fakeRows) RawValues() [][]byte {
+	return r.values
+}
 
-		mockBeginner := mock_driver.NewMockBeginner(ctrl)
-		mockBeginner.
-			EXPECT().
-			Begin(gomock.Any()).
-			Return(nil, errors.New(""))
+func TestRunEagerQueryRow(t *testing.T) {
+	t.Parallel()
 
-		mockTracer := mock_trace.NewMockLogger(ctrl)
-		mockTracer.
+	testCases := map[string]struct {
+		Rows  pgx.Rows
+		Err   error
+		Log   string
+		Check func(row adapter.Row)
+	}{
+		"success": {
+			Rows: &fakeRows{values: [][]byte{[]byte("42")}},
+			Log:  "executed",
+			Check: func(row adapter.Row) {
+				require.NoError(t, row.Err())
+
+				var id int
+				require.NoError(t, row.Scan(&id))
+				require.Equal(t, 42, id)
+			},
+		},
+		"no_rows": {
+			Rows: &fakeRows{},
+			Log:  "executed",
+			Check: func(row adapter.Row) {
+				require.NoError(t, row.Err())
+				require.ErrorIs(t, row.Scan(), adapter.ErrNoRows)
+			},
+		},
+		"failure": {
+			Err: &pgconn.PgError{Code: pgerrcode.UniqueViolation},
+			Log: "failed to execute",
+			Check: func(row adapter.Row) {
+				require.ErrorIs(t, row.Err(), adapter.ErrUniqueViolation)
+				require.ErrorIs(t, row.Scan(), adapter.ErrUniqueViolation)
+			},
+		},
+		"rows_failure": {
+			Rows: &fakeRows{
+				values: [][]byte{[]byte("42")},
+				err:    &pgconn.PgError{Code: pgerrcode.DivisionByZero},
+			},
+			Log: "failed to execute",
+			Check: func(row adapter.Row) {
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, row.Err(), &pgErr)
+				require.Equal(t, pgerrcode.DivisionByZero, pgErr.Code)
+			},
+		},
+		"timeout": {
+			Err: context.DeadlineExceeded,
+			Log: "execution timed out",
+			Check: func(row adapter.Row) {
+				require.ErrorIs(t, row.Err(), adapter.ErrQueryTimeout)
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			mockQuerier := mock_driver.NewMockQuerier(ctrl)
+			mockQuerier.
+				EXPECT().
+				Query(gomock.Any(), "").
+				Return(testCase.Rows, testCase.Err)
+
+			mockTracer := mock_trace.NewMockLogger(ctrl)
+			mockTracer.
+				EXPECT().
+				WithCallerSkip(gomock.Any()).
+				Return(mockTracer)
+			mockTracer.
+				EXPECT().
+				With(gomock.Any()).
+				Return(mockTracer)
+			mockTracer.
+				EXPECT().
+				Log(gomock.Any(), testCase.Log, gomock.Any())
+			mockTracer.
+				EXPECT().
+				Log(gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes()
+
+			row := runEagerQueryRow(
+				mockQuerier,
+				mockTracer,
+				newOptions(nil),
+				context.Background(),
+				"",
+			)
+			testCase.Check(row)
+		})
+	}
+}
+
+func TestRunPrepare(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockConn := mock_driver.NewMockConn(ctrl)
+
+	mockTracer := mock_trace.NewMockLogger(ctrl)
+	mockTracer.
+		EXPECT().
+		WithCallerSkip(gomock.Any()).
+		Return(mockTracer)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "prepared a statement", gomock.Any())
+
+	_, err := runPrepare(
+		NewConn(mockConn, mockTracer),
+		mockTracer,
+		context.Background(),
+		"",
+	)
+	require.NoError(t, err)
+}
+
+func TestRunBegin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockBeginner := mock_driver.NewMockBeginner(ctrl)
+		mockBeginner.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(nil, nil)
+
+		// The transaction is traced with its id and depth.
+		mockTxTracer := mock_trace.NewMockLogger(ctrl)
+		mockTxTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "began a transaction", gomock.Any())
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			With(gomock.Cond(func(fields map[string]any) bool {
+				return fields[trace.TxIDKey] != "" &&
+					fields[trace.TxDepthKey] == 0
+			})).
+			Return(mockTxTracer)
+		mockTracer.
+			EXPECT().
+			WithCallerSkip(gomock.Any()).
+			Return(mockTracer)
+
+		_, err := runBegin(
+			mockBeginner,
+			mockTracer,
+			newOptions(nil),
+			nil,
+			func() {},
+			context.Background(),
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		mockBeginner := mock_driver.NewMockBeginner(ctrl)
+		mockBeginner.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(nil, errors.New(""))
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer).
+			AnyTimes()
+		mockTracer.
 			EXPECT().
 			WithCallerSkip(gomock.Any()).
 			Return(mockTracer)
@@ -848,7 +1747,901 @@ func TestRunBegin(t *testing.T) {
 			EXPECT().
 			Log(trace.ErrorLevel, "failed to begin a transaction", gomock.Any())
 
-		_, err := runBegin(mockBeginner, mockTracer, context.Background())
+		_, err := runBegin(
+			mockBeginner,
+			mockTracer,
+			newOptions(nil),
+			nil,
+			func() {},
+			context.Background(),
+		)
 		require.Error(t, err)
 	})
 }
+
+func TestRunBeginTx(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		TxOptions adapter.TxOptions
+		Expect    func(
+			ctrl *gomock.Controller,
+			mockTxBeginner *mock_driver.MockTxBeginner,
+		)
+		Log   string
+		Check func(err error)
+		// Queries is the number of statements run to configure
+		// the transaction once it has begun.
+		Queries int
+	}{
+		"default": {
+			Expect: func(
+				_ *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{}).
+					Return(nil, nil)
+			},
+			Log: "began a transaction",
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"serializable_read_only_deferrable": {
+			TxOptions: adapter.TxOptions{
+				Isolation:  adapter.LevelSerializable,
+				AccessMode: adapter.AccessModeReadOnly,
+				Deferrable: true,
+			},
+			Expect: func(
+				_ *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{
+						IsoLevel:       pgx.Serializable,
+						AccessMode:     pgx.ReadOnly,
+						DeferrableMode: pgx.Deferrable,
+					}).
+					Return(nil, nil)
+			},
+			Log: "began a transaction",
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"failure": {
+			TxOptions: adapter.TxOptions{
+				Isolation: adapter.LevelRepeatableRead,
+			},
+			Expect: func(
+				_ *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{
+						IsoLevel: pgx.RepeatableRead,
+					}).
+					Return(nil, errors.New(""))
+			},
+			Log: "failed to begin a transaction",
+			Check: func(err error) {
+				require.Error(t, err)
+			},
+		},
+		"settings": {
+			TxOptions: adapter.TxOptions{
+				Settings: map[string]string{
+					"app.user_id":   "7",
+					"app.tenant_id": "42",
+				},
+				Role: "tenant",
+			},
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTx := mock_driver.NewMockTx(ctrl)
+				mockTx.
+					EXPECT().
+					Exec(
+						gomock.Any(),
+						applySettingsQuery,
+						[]string{"app.tenant_id", "app.user_id", "role"},
+						[]string{"42", "7", "tenant"},
+					)
+
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{}).
+					Return(pgxTx{driverTx: mockTx}, nil)
+			},
+			Log:     "began a transaction",
+			Queries: 1,
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"settings_failure": {
+			TxOptions: adapter.TxOptions{
+				Role: "unknown",
+			},
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTx := mock_driver.NewMockTx(ctrl)
+				mockTx.
+					EXPECT().
+					Exec(
+						gomock.Any(),
+						applySettingsQuery,
+						[]string{"role"},
+						[]string{"unknown"},
+					).
+					Return(pgconn.CommandTag{}, &pgconn.PgError{
+						Code: pgerrcode.InvalidParameterValue,
+					})
+				mockTx.
+					EXPECT().
+					Rollback(gomock.Any())
+
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{}).
+					Return(pgxTx{driverTx: mockTx}, nil)
+			},
+			Log: "failed to begin a transaction",
+			Check: func(err error) {
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
+			},
+		},
+		"snapshot": {
+			TxOptions: adapter.TxOptions{
+				Snapshot: "00000003-0000001B-1",
+			},
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTx := mock_driver.NewMockTx(ctrl)
+				mockTx.
+					EXPECT().
+					Exec(
+						gomock.Any(),
+						"SET TRANSACTION SNAPSHOT '00000003-0000001B-1'",
+					)
+
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{
+						IsoLevel: pgx.RepeatableRead,
+					}).
+					Return(pgxTx{driverTx: mockTx}, nil)
+			},
+			Log:     "began a transaction",
+			Queries: 1,
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"snapshot_unknown": {
+			TxOptions: adapter.TxOptions{
+				Isolation: adapter.LevelSerializable,
+				Snapshot:  "00000003-0000001B-1",
+			},
+			Expect: func(
+				ctrl *gomock.Controller,
+				mockTxBeginner *mock_driver.MockTxBeginner,
+			) {
+				mockTx := mock_driver.NewMockTx(ctrl)
+				mockTx.
+					EXPECT().
+					Exec(
+						gomock.Any(),
+						"SET TRANSACTION SNAPSHOT '00000003-0000001B-1'",
+					).
+					Return(pgconn.CommandTag{}, &pgconn.PgError{
+						Code: pgerrcode.InvalidParameterValue,
+					})
+				mockTx.
+					EXPECT().
+					Rollback(gomock.Any())
+
+				mockTxBeginner.
+					EXPECT().
+					BeginTx(gomock.Any(), pgx.TxOptions{
+						IsoLevel: pgx.Serializable,
+					}).
+					Return(pgxTx{driverTx: mockTx}, nil)
+			},
+			Log: "failed to begin a transaction",
+			Check: func(err error) {
+				require.ErrorIs(t, err, adapter.ErrSnapshotInvalid)
+
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
+			},
+		},
+		"snapshot_malformed": {
+			TxOptions: adapter.TxOptions{
+				Snapshot: "'; DROP TABLE users; --",
+			},
+			Expect: func(*gomock.Controller, *mock_driver.MockTxBeginner) {},
+			Log:    "failed to begin a transaction",
+			Check: func(err error) {
+				require.ErrorIs(t, err, adapter.ErrSnapshotInvalid)
+			},
+		},
+		"snapshot_read_committed": {
+			TxOptions: adapter.TxOptions{
+				Isolation: adapter.LevelReadCommitted,
+				Snapshot:  "00000003-0000001B-1",
+			},
+			Expect: func(*gomock.Controller, *mock_driver.MockTxBeginner) {},
+			Log:    "failed to begin a transaction",
+			Check: func(err error) {
+				require.ErrorIs(t, err, adapter.ErrUnsupportedTxOptions)
+			},
+		},
+		"unknown_isolation_level": {
+			TxOptions: adapter.TxOptions{
+				Isolation: adapter.IsolationLevel(-1),
+			},
+			Expect: func(*gomock.Controller, *mock_driver.MockTxBeginner) {},
+			Log:    "failed to begin a transaction",
+			Check: func(err error) {
+				require.ErrorIs(t, err, adapter.ErrUnsupportedTxOptions)
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			mockTxBeginner := mock_driver.NewMockTxBeginner(ctrl)
+			testCase.Expect(ctrl, mockTxBeginner)
+
+			mockTracer := mock_trace.NewMockLogger(ctrl)
+			mockTracer.
+				EXPECT().
+				With(gomock.Any()).
+				Return(mockTracer).
+				AnyTimes()
+			mockTracer.
+				EXPECT().
+				WithCallerSkip(gomock.Any()).
+				Return(mockTracer)
+			mockTracer.
+				EXPECT().
+				Log(gomock.Any(), testCase.Log, gomock.Cond(
+					func(fields map[string]any) bool {
+						return reflect.DeepEqual(
+							fields[trace.TxOptionsKey],
+							redactTxOptions(testCase.TxOptions),
+						)
+					},
+				))
+
+			tx, err := runBeginTx(
+				mockTxBeginner,
+				mockTracer,
+				newOptions(nil),
+				func() {},
+				context.Background(),
+				testCase.TxOptions,
+			)
+			testCase.Check(err)
+			if err == nil {
+				require.Equal(t, testCase.Queries, tx.(Tx).state.queries)
+			}
+		})
+	}
+}
+
+// pgxTx makes a driver transaction a pgx.Tx,
+// which is what the driver returns when a transaction begins.
+type pgxTx struct {
+	pgx.Tx
+	driverTx driver.Tx
+}
+
+func (t pgxTx) Exec(
+	ctx context.Context,
+	query string,
+	args ...any,
+) (pgconn.CommandTag, error) {
+	return t.driverTx.Exec(ctx, query, args...)
+}
+
+func (t pgxTx) QueryRow(
+	ctx context.Context,
+	query string,
+	args ...any,
+) pgx.Row {
+	return t.driverTx.QueryRow(ctx, query, args...)
+}
+
+func (t pgxTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.driverTx.Begin(ctx)
+}
+
+func (t pgxTx) Commit(ctx context.Context) error {
+	return t.driverTx.Commit(ctx)
+}
+
+func (t pgxTx) Rollback(ctx context.Context) error {
+	return t.driverTx.Rollback(ctx)
+}
+
+// pgxRows makes driver rows pgx.Rows,
+// which is what the driver returns when a query is run.
+type pgxRows struct {
+	pgx.Rows
+	driverRows driver.Rows
+}
+
+func (r pgxRows) Close() {
+	r.driverRows.Close()
+}
+
+func (r pgxRows) Err() error {
+	return r.driverRows.Err()
+}
+
+func (r pgxRows) Next() bool {
+	return r.driverRows.Next()
+}
+
+func (r pgxRows) Scan(dest ...any) error {
+	return r.driverRows.Scan(dest...)
+}
+
+// newPermissiveTracer returns a tracer that accepts any fields
+// and caller skip, so that the tests only expect the logs.
+func newPermissiveTracer(ctrl *gomock.Controller) *mock_trace.MockLogger {
+	mockTracer := mock_trace.NewMockLogger(ctrl)
+	mockTracer.
+		EXPECT().
+		WithCallerSkip(gomock.Any()).
+		Return(mockTracer).
+		AnyTimes()
+	mockTracer.
+		EXPECT().
+		With(gomock.Any()).
+		Return(mockTracer).
+		AnyTimes()
+	return mockTracer
+}
+
+func TestRunBeginTx_Retry(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockTx := mock_driver.NewMockTx(ctrl)
+	gomock.InOrder(
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any()).
+			Return(&pgconn.PgError{Code: pgerrcode.SerializationFailure}),
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any()).
+			Return(nil),
+	)
+
+	mockConn := mock_driver.NewMockConn(ctrl)
+	mockConn.
+		EXPECT().
+		BeginTx(gomock.Any(), gomock.Any()).
+		Return(pgxTx{driverTx: mockTx}, nil).
+		Times(2)
+
+	mockTracer := newPermissiveTracer(ctrl)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "retrying a transaction", gomock.Cond(
+			func(fields map[string]any) bool {
+				return fields[trace.AttemptKey] == 2 &&
+					errors.Is(
+						fields[trace.ErrorKey].(error),
+						adapter.ErrSerializationFailure,
+					)
+			},
+		))
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "began a transaction", gomock.Cond(
+			func(fields map[string]any) bool {
+				attempt := fields[trace.AttemptKey]
+				return attempt == 1 || attempt == 2
+			},
+		)).
+		Times(2)
+	mockTracer.
+		EXPECT().
+		Log(gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	err := adapter.RunInTxWithRetry(
+		context.Background(),
+		NewConn(mockConn, mockTracer),
+		adapter.TxOptions{Isolation: adapter.LevelSerializable},
+		adapter.RetryPolicy{MaxAttempts: 2},
+		func(context.Context, adapter.Tx) error {
+			return nil
+		},
+	)
+	require.NoError(t, err)
+}
+
+func TestConn_LeakReport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Rollback(gomock.Any()).
+			Return(nil)
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Query(gomock.Any(), "SELECT id FROM users").
+			Return(&fakeRows{values: [][]byte{[]byte("1")}}, nil).
+			Times(2)
+		mockConn.
+			EXPECT().
+			BeginTx(gomock.Any(), gomock.Any()).
+			Return(pgxTx{driverTx: mockTx}, nil).
+			Times(2)
+		mockConn.
+			EXPECT().
+			Close()
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "found a leak", gomock.Cond(
+				func(fields map[string]any) bool {
+					return fields[trace.LeakKey] == LeakKindTx
+				},
+			))
+		mockTracer.
+			EXPECT().
+			Log(gomock.Not(trace.ErrorLevel), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		conn := NewConn(mockConn, mockTracer, WithLeakDetection(0))
+		require.Empty(t, conn.LeakReport())
+
+		rows, err := conn.Query(ctx, "SELECT id FROM users")
+		require.NoError(t, err)
+		tx, err := conn.BeginTx(ctx, adapter.TxOptions{})
+		require.NoError(t, err)
+
+		leaks := conn.LeakReport()
+		require.Len(t, leaks, 2)
+		for _, leak := range leaks {
+			require.Contains(t, leak.Stack, "TestConn_LeakReport")
+			if leak.Kind == LeakKindRows {
+				require.Equal(t, "SELECT id FROM users", leak.Query)
+			} else {
+				require.Equal(t, LeakKindTx, leak.Kind)
+			}
+		}
+
+		require.NoError(t, rows.Close())
+		require.NoError(t, tx.Rollback(ctx))
+		require.Empty(t, conn.LeakReport())
+
+		// Rows are closed once they are over.
+		rows, err = conn.Query(ctx, "SELECT id FROM users")
+		require.NoError(t, err)
+		for rows.Next() {
+		}
+		require.Empty(t, conn.LeakReport())
+
+		_, err = conn.BeginTx(ctx, adapter.TxOptions{})
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	})
+
+	t.Run("max_age", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			BeginTx(gomock.Any(), gomock.Any()).
+			Return(pgxTx{}, nil)
+
+		reported := make(chan time.Duration, 1)
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			WithCallerSkip(gomock.Any()).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "began a transaction", gomock.Any())
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "found a leak", gomock.Any()).
+			Do(func(_ trace.Level, _ string, fields map[string]any) {
+				reported <- fields[trace.DurationKey].(time.Duration)
+			})
+
+		conn := NewConn(
+			mockConn,
+			mockTracer,
+			WithLeakDetection(time.Millisecond),
+		)
+		_, err := conn.BeginTx(context.Background(), adapter.TxOptions{})
+		require.NoError(t, err)
+
+		select {
+		case age := <-reported:
+			require.GreaterOrEqual(t, age, time.Millisecond)
+		case <-time.After(time.Second):
+			t.Fatal("the leak has not been reported")
+		}
+	})
+}
+
+func TestTx_Watchdog(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		Option Option
+	}{
+		"max_lifetime": {
+			Option: WithTxMaxLifetime(20 * time.Millisecond),
+		},
+		"max_idle": {
+			Option: WithTxMaxIdle(20 * time.Millisecond),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			mockTx := mock_driver.NewMockTx(ctrl)
+			mockTx.
+				EXPECT().
+				Exec(gomock.Any(), "UPDATE users SET name = $1", "").
+				Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+			mockTx.
+				EXPECT().
+				Rollback(gomock.Any()).
+				Return(nil)
+
+			expired := make(chan map[string]any, 1)
+
+			mockTracer := newPermissiveTracer(ctrl)
+			mockTracer.
+				EXPECT().
+				Log(
+					trace.ErrorLevel,
+					"rolled back an expired transaction",
+					gomock.Any(),
+				).
+				Do(func(_ trace.Level, _ string, fields map[string]any) {
+					expired <- fields
+				})
+			mockTracer.
+				EXPECT().
+				Log(gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes()
+
+			ctx := context.Background()
+			tx := NewTx(mockTx, mockTracer, testCase.Option)
+
+			hookErrs := make(chan error, 1)
+			tx.OnRollback(func(_ context.Context, err error) {
+				hookErrs <- err
+			})
+
+			_, err := tx.Exec(ctx, "UPDATE users SET name = $1", "")
+			require.NoError(t, err)
+
+			select {
+			case fields := <-expired:
+				require.Equal(
+					t,
+					"UPDATE users SET name = $1",
+					fields[trace.QueryKey],
+				)
+				require.Greater(t, fields[trace.IdleKey], time.Duration(0))
+			case <-time.After(time.Second):
+				t.Fatal("the transaction has not expired")
+			}
+
+			_, err = tx.Exec(ctx, "UPDATE users SET name = $1", "")
+			require.ErrorIs(t, err, adapter.ErrTxExpired)
+			require.ErrorIs(t, tx.Commit(ctx), adapter.ErrTxExpired)
+			require.ErrorIs(t, tx.Rollback(ctx), adapter.ErrTxExpired)
+			require.ErrorIs(t, <-hookErrs, adapter.ErrTxExpired)
+		})
+	}
+
+	t.Run("active", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), "SELECT 1").
+			Return(pgconn.NewCommandTag("SELECT 1"), nil).
+			Times(3)
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any()).
+			Return(nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer, WithTxMaxIdle(50*time.Millisecond))
+
+		for range 3 {
+			time.Sleep(25 * time.Millisecond)
+			_, err := tx.Exec(ctx, "SELECT 1")
+			require.NoError(t, err)
+		}
+		require.NoError(t, tx.Commit(ctx))
+	})
+
+	t.Run("open_rows", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRows := mock_driver.NewMockRows(ctrl)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Query(gomock.Any(), "SELECT 1").
+			Return(pgxRows{driverRows: mockRows}, nil)
+
+		expired := make(chan struct{}, 1)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(
+				trace.ErrorLevel,
+				"rolled back an expired transaction",
+				gomock.Any(),
+			).
+			Do(func(trace.Level, string, map[string]any) {
+				expired <- struct{}{}
+			})
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer, WithTxMaxIdle(20*time.Millisecond))
+
+		rows, err := tx.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+
+		// The transaction is not idle while the rows are open.
+		select {
+		case <-expired:
+			t.Fatal("the transaction has expired with the rows open")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		gomock.InOrder(
+			mockRows.
+				EXPECT().
+				Close(),
+			mockTx.
+				EXPECT().
+				Rollback(gomock.Any()).
+				Return(nil),
+		)
+		require.NoError(t, rows.Close())
+
+		select {
+		case <-expired:
+		case <-time.After(time.Second):
+			t.Fatal("the transaction has not expired")
+		}
+	})
+
+	// The watchdog does not wait for the guarded connection,
+	// which the row holds until it is scanned.
+	t.Run("unscanned_row", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRow := mock_driver.NewMockRow(ctrl)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			QueryRow(gomock.Any(), "SELECT 1").
+			Return(mockRow)
+
+		expired := make(chan struct{}, 1)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(
+				trace.ErrorLevel,
+				"rolled back an expired transaction",
+				gomock.Any(),
+			).
+			Do(func(trace.Level, string, map[string]any) {
+				expired <- struct{}{}
+			})
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		tx := NewTx(
+			mockTx,
+			mockTracer,
+			WithTxMaxLifetime(20*time.Millisecond),
+			WithConcurrencyGuard(GuardSerialize),
+		)
+
+		row := tx.QueryRow(ctx, "SELECT 1")
+
+		select {
+		case <-expired:
+			t.Fatal("the transaction has expired with the row unscanned")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		gomock.InOrder(
+			mockRow.
+				EXPECT().
+				Scan().
+				Return(nil),
+			mockTx.
+				EXPECT().
+				Rollback(gomock.Any()).
+				Return(nil),
+		)
+		require.NoError(t, row.Scan())
+
+		select {
+		case <-expired:
+		case <-time.After(time.Second):
+			t.Fatal("the transaction has not expired")
+		}
+	})
+}
+
+func TestTx_Summary(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockNestedTx := mock_driver.NewMockTx(ctrl)
+	mockNestedTx.
+		EXPECT().
+		Exec(gomock.Any(), "DELETE FROM users").
+		Return(pgconn.NewCommandTag("DELETE 1"), nil)
+	mockNestedTx.
+		EXPECT().
+		Commit(gomock.Any()).
+		Return(nil)
+
+	mockTx := mock_driver.NewMockTx(ctrl)
+	mockTx.
+		EXPECT().
+		Exec(gomock.Any(), "UPDATE users SET name = $1", "").
+		Return(pgconn.NewCommandTag("UPDATE 2"), nil)
+	mockTx.
+		EXPECT().
+		Exec(gomock.Any(), "UPDATE users SET name = $1", "").
+		Return(pgconn.CommandTag{}, errors.New(""))
+	mockTx.
+		EXPECT().
+		Begin(gomock.Any()).
+		Return(pgxTx{driverTx: mockNestedTx}, nil)
+	mockTx.
+		EXPECT().
+		Commit(gomock.Any()).
+		Return(nil)
+
+	var txFields []map[string]any
+
+	mockTracer := mock_trace.NewMockLogger(ctrl)
+	mockTracer.
+		EXPECT().
+		WithCallerSkip(gomock.Any()).
+		Return(mockTracer).
+		AnyTimes()
+	mockTracer.
+		EXPECT().
+		With(gomock.Any()).
+		DoAndReturn(func(fields map[string]any) trace.Logger {
+			if _, ok := fields[trace.TxIDKey]; ok {
+				txFields = append(txFields, fields)
+			}
+			return mockTracer
+		}).
+		AnyTimes()
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "committed a transaction", gomock.Cond(
+			func(fields map[string]any) bool {
+				return fields[trace.QueryCountKey] == 1 &&
+					fields[trace.RowsAffectedKey] == int64(1)
+			},
+		))
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "committed a transaction", gomock.Cond(
+			func(fields map[string]any) bool {
+				return fields[trace.QueryCountKey] == 3 &&
+					fields[trace.RowsAffectedKey] == int64(3) &&
+					fields[trace.DBTimeKey].(time.Duration) <=
+						fields[trace.DurationKey].(time.Duration)
+			},
+		))
+	mockTracer.
+		EXPECT().
+		Log(
+			gomock.Any(),
+			gomock.Not("committed a transaction"),
+			gomock.Any(),
+		).
+		AnyTimes()
+
+	ctx := context.Background()
+	tx := NewTx(mockTx, mockTracer)
+
+	_, err := tx.Exec(ctx, "UPDATE users SET name = $1", "")
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "UPDATE users SET name = $1", "")
+	require.Error(t, err)
+
+	nestedTx, err := tx.Begin(ctx)
+	require.NoError(t, err)
+	_, err = nestedTx.Exec(ctx, "DELETE FROM users")
+	require.NoError(t, err)
+
+	require.NoError(t, nestedTx.Commit(ctx))
+	require.NoError(t, tx.Commit(ctx))
+
+	require.Len(t, txFields, 2)
+	require.Equal(t, 0, txFields[0][trace.TxDepthKey])
+	require.Equal(t, 1, txFields[1][trace.TxDepthKey])
+	require.NotEmpty(t, txFields[0][trace.TxIDKey])
+	require.Equal(t, txFields[0][trace.TxIDKey], txFields[1][trace.TxIDKey])
+}
@@ -1,8 +1,9 @@
-//go:generate mockgen -typed -destination mock/driver.go . Result,Row,Rows,Execer,Querier,RowQuerier,Beginner,Conn,Tx
+//go:generate mockgen -typed -destination mock/driver.go . Result,Row,Rows,Execer,Querier,RowQuerier,Beginner,TxBeginner,Conn,Tx,PoolConn,PoolStat,Pool
 package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -43,11 +44,16 @@ type Beginner interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
+type TxBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
 type Conn interface {
 	Execer
 	Querier
 	RowQuerier
 	Beginner
+	TxBeginner
 	Ping(ctx context.Context) error
 	Close()
 }
@@ -60,3 +66,32 @@ type Tx interface {
 	Commit(ctx context.Context) error
 	Rollback(ctx context.Context) error
 }
+
+type PoolConn interface {
+	Execer
+	Querier
+	RowQuerier
+	Beginner
+	TxBeginner
+	Ping(ctx context.Context) error
+	// Release returns the connection to the pool.
+	Release()
+}
+
+type PoolStat interface {
+	MaxConns() int32
+	TotalConns() int32
+	AcquiredConns() int32
+	IdleConns() int32
+	ConstructingConns() int32
+	AcquireCount() int64
+	AcquireDuration() time.Duration
+	EmptyAcquireCount() int64
+	CanceledAcquireCount() int64
+}
+
+type Pool interface {
+	Acquire(ctx context.Context) (PoolConn, error)
+	Stat() PoolStat
+	Close()
+}
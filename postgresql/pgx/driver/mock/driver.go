@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver (interfaces: Result,Row,Rows,Execer,Querier,RowQuerier,Beginner,Conn,Tx)
+// Source: github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver (interfaces: Result,Row,Rows,Execer,Querier,RowQuerier,Beginner,TxBeginner,Conn,Tx,PoolConn,PoolStat,Pool)
 //
 // Generated by this command:
 //
-//	mockgen -typed -destination mock/driver.go . Result,Row,Rows,Execer,Querier,RowQuerier,Beginner,Conn,Tx
+//	mockgen -typed -destination mock/driver.go . Result,Row,Rows,Execer,Querier,RowQuerier,Beginner,TxBeginner,Conn,Tx,PoolConn,PoolStat,Pool
 //
 
 // Package mock_driver is a generated GoMock package.
@@ -12,7 +12,9 @@ package mock_driver
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	driver "github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver"
 	pgx "github.com/jackc/pgx/v5"
 	pgconn "github.com/jackc/pgx/v5/pgconn"
 	gomock "go.uber.org/mock/gomock"
@@ -590,6 +592,69 @@ func (c *MockBeginnerBeginCall) DoAndReturn(f func(context.Context) (pgx.Tx, err
 	return c
 }
 
+// MockTxBeginner is a mock of TxBeginner interface.
+type MockTxBeginner struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxBeginnerMockRecorder
+	isgomock struct{}
+}
+
+// MockTxBeginnerMockRecorder is the mock recorder for MockTxBeginner.
+type MockTxBeginnerMockRecorder struct {
+	mock *MockTxBeginner
+}
+
+// NewMockTxBeginner creates a new mock instance.
+func NewMockTxBeginner(ctrl *gomock.Controller) *MockTxBeginner {
+	mock := &MockTxBeginner{ctrl: ctrl}
+	mock.recorder = &MockTxBeginnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxBeginner) EXPECT() *MockTxBeginnerMockRecorder {
+	return m.recorder
+}
+
+// BeginTx mocks base method.
+func (m *MockTxBeginner) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTx", ctx, txOptions)
+	ret0, _ := ret[0].(pgx.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockTxBeginnerMockRecorder) BeginTx(ctx, txOptions any) *MockTxBeginnerBeginTxCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockTxBeginner)(nil).BeginTx), ctx, txOptions)
+	return &MockTxBeginnerBeginTxCall{Call: call}
+}
+
+// MockTxBeginnerBeginTxCall wrap *gomock.Call
+type MockTxBeginnerBeginTxCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTxBeginnerBeginTxCall) Return(arg0 pgx.Tx, arg1 error) *MockTxBeginnerBeginTxCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTxBeginnerBeginTxCall) Do(f func(context.Context, pgx.TxOptions) (pgx.Tx, error)) *MockTxBeginnerBeginTxCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTxBeginnerBeginTxCall) DoAndReturn(f func(context.Context, pgx.TxOptions) (pgx.Tx, error)) *MockTxBeginnerBeginTxCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MockConn is a mock of Conn interface.
 type MockConn struct {
 	ctrl     *gomock.Controller
@@ -653,6 +718,45 @@ func (c *MockConnBeginCall) DoAndReturn(f func(context.Context) (pgx.Tx, error))
 	return c
 }
 
+// BeginTx mocks base method.
+func (m *MockConn) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTx", ctx, txOptions)
+	ret0, _ := ret[0].(pgx.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockConnMockRecorder) BeginTx(ctx, txOptions any) *MockConnBeginTxCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockConn)(nil).BeginTx), ctx, txOptions)
+	return &MockConnBeginTxCall{Call: call}
+}
+
+// MockConnBeginTxCall wrap *gomock.Call
+type MockConnBeginTxCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockConnBeginTxCall) Return(arg0 pgx.Tx, arg1 error) *MockConnBeginTxCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockConnBeginTxCall) Do(f func(context.Context, pgx.TxOptions) (pgx.Tx, error)) *MockConnBeginTxCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockConnBeginTxCall) DoAndReturn(f func(context.Context, pgx.TxOptions) (pgx.Tx, error)) *MockConnBeginTxCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Close mocks base method.
 func (m *MockConn) Close() {
 	m.ctrl.T.Helper()
@@ -1127,3 +1231,813 @@ func (c *MockTxRollbackCall) DoAndReturn(f func(context.Context) error) *MockTxR
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// MockPoolConn is a mock of PoolConn interface.
+type MockPoolConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockPoolConnMockRecorder
+	isgomock struct{}
+}
+
+// MockPoolConnMockRecorder is the mock recorder for MockPoolConn.
+type MockPoolConnMockRecorder struct {
+	mock *MockPoolConn
+}
+
+// NewMockPoolConn creates a new mock instance.
+func NewMockPoolConn(ctrl *gomock.Controller) *MockPoolConn {
+	mock := &MockPoolConn{ctrl: ctrl}
+	mock.recorder = &MockPoolConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPoolConn) EXPECT() *MockPoolConnMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockPoolConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx)
+	ret0, _ := ret[0].(pgx.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockPoolConnMockRecorder) Begin(ctx any) *MockPoolConnBeginCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockPoolConn)(nil).Begin), ctx)
+	return &MockPoolConnBeginCall{Call: call}
+}
+
+// MockPoolConnBeginCall wrap *gomock.Call
+type MockPoolConnBeginCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnBeginCall) Return(arg0 pgx.Tx, arg1 error) *MockPoolConnBeginCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnBeginCall) Do(f func(context.Context) (pgx.Tx, error)) *MockPoolConnBeginCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnBeginCall) DoAndReturn(f func(context.Context) (pgx.Tx, error)) *MockPoolConnBeginCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// BeginTx mocks base method.
+func (m *MockPoolConn) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTx", ctx, txOptions)
+	ret0, _ := ret[0].(pgx.Tx)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockPoolConnMockRecorder) BeginTx(ctx, txOptions any) *MockPoolConnBeginTxCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockPoolConn)(nil).BeginTx), ctx, txOptions)
+	return &MockPoolConnBeginTxCall{Call: call}
+}
+
+// MockPoolConnBeginTxCall wrap *gomock.Call
+type MockPoolConnBeginTxCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnBeginTxCall) Return(arg0 pgx.Tx, arg1 error) *MockPoolConnBeginTxCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnBeginTxCall) Do(f func(context.Context, pgx.TxOptions) (pgx.Tx, error)) *MockPoolConnBeginTxCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnBeginTxCall) DoAndReturn(f func(context.Context, pgx.TxOptions) (pgx.Tx, error)) *MockPoolConnBeginTxCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Exec mocks base method.
+func (m *MockPoolConn) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Exec", varargs...)
+	ret0, _ := ret[0].(pgconn.CommandTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockPoolConnMockRecorder) Exec(ctx, query any, args ...any) *MockPoolConnExecCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, query}, args...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockPoolConn)(nil).Exec), varargs...)
+	return &MockPoolConnExecCall{Call: call}
+}
+
+// MockPoolConnExecCall wrap *gomock.Call
+type MockPoolConnExecCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnExecCall) Return(arg0 pgconn.CommandTag, arg1 error) *MockPoolConnExecCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnExecCall) Do(f func(context.Context, string, ...any) (pgconn.CommandTag, error)) *MockPoolConnExecCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnExecCall) DoAndReturn(f func(context.Context, string, ...any) (pgconn.CommandTag, error)) *MockPoolConnExecCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Ping mocks base method.
+func (m *MockPoolConn) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockPoolConnMockRecorder) Ping(ctx any) *MockPoolConnPingCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockPoolConn)(nil).Ping), ctx)
+	return &MockPoolConnPingCall{Call: call}
+}
+
+// MockPoolConnPingCall wrap *gomock.Call
+type MockPoolConnPingCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnPingCall) Return(arg0 error) *MockPoolConnPingCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnPingCall) Do(f func(context.Context) error) *MockPoolConnPingCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnPingCall) DoAndReturn(f func(context.Context) error) *MockPoolConnPingCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Query mocks base method.
+func (m *MockPoolConn) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(pgx.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockPoolConnMockRecorder) Query(ctx, query any, args ...any) *MockPoolConnQueryCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, query}, args...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockPoolConn)(nil).Query), varargs...)
+	return &MockPoolConnQueryCall{Call: call}
+}
+
+// MockPoolConnQueryCall wrap *gomock.Call
+type MockPoolConnQueryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnQueryCall) Return(arg0 pgx.Rows, arg1 error) *MockPoolConnQueryCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnQueryCall) Do(f func(context.Context, string, ...any) (pgx.Rows, error)) *MockPoolConnQueryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnQueryCall) DoAndReturn(f func(context.Context, string, ...any) (pgx.Rows, error)) *MockPoolConnQueryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// QueryRow mocks base method.
+func (m *MockPoolConn) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRow", varargs...)
+	ret0, _ := ret[0].(pgx.Row)
+	return ret0
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockPoolConnMockRecorder) QueryRow(ctx, query any, args ...any) *MockPoolConnQueryRowCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, query}, args...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockPoolConn)(nil).QueryRow), varargs...)
+	return &MockPoolConnQueryRowCall{Call: call}
+}
+
+// MockPoolConnQueryRowCall wrap *gomock.Call
+type MockPoolConnQueryRowCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnQueryRowCall) Return(arg0 pgx.Row) *MockPoolConnQueryRowCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnQueryRowCall) Do(f func(context.Context, string, ...any) pgx.Row) *MockPoolConnQueryRowCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnQueryRowCall) DoAndReturn(f func(context.Context, string, ...any) pgx.Row) *MockPoolConnQueryRowCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Release mocks base method.
+func (m *MockPoolConn) Release() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release")
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockPoolConnMockRecorder) Release() *MockPoolConnReleaseCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockPoolConn)(nil).Release))
+	return &MockPoolConnReleaseCall{Call: call}
+}
+
+// MockPoolConnReleaseCall wrap *gomock.Call
+type MockPoolConnReleaseCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolConnReleaseCall) Return() *MockPoolConnReleaseCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolConnReleaseCall) Do(f func()) *MockPoolConnReleaseCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolConnReleaseCall) DoAndReturn(f func()) *MockPoolConnReleaseCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockPoolStat is a mock of PoolStat interface.
+type MockPoolStat struct {
+	ctrl     *gomock.Controller
+	recorder *MockPoolStatMockRecorder
+	isgomock struct{}
+}
+
+// MockPoolStatMockRecorder is the mock recorder for MockPoolStat.
+type MockPoolStatMockRecorder struct {
+	mock *MockPoolStat
+}
+
+// NewMockPoolStat creates a new mock instance.
+func NewMockPoolStat(ctrl *gomock.Controller) *MockPoolStat {
+	mock := &MockPoolStat{ctrl: ctrl}
+	mock.recorder = &MockPoolStatMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPoolStat) EXPECT() *MockPoolStatMockRecorder {
+	return m.recorder
+}
+
+// AcquireCount mocks base method.
+func (m *MockPoolStat) AcquireCount() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireCount")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// AcquireCount indicates an expected call of AcquireCount.
+func (mr *MockPoolStatMockRecorder) AcquireCount() *MockPoolStatAcquireCountCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireCount", reflect.TypeOf((*MockPoolStat)(nil).AcquireCount))
+	return &MockPoolStatAcquireCountCall{Call: call}
+}
+
+// MockPoolStatAcquireCountCall wrap *gomock.Call
+type MockPoolStatAcquireCountCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatAcquireCountCall) Return(arg0 int64) *MockPoolStatAcquireCountCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatAcquireCountCall) Do(f func() int64) *MockPoolStatAcquireCountCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatAcquireCountCall) DoAndReturn(f func() int64) *MockPoolStatAcquireCountCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// AcquireDuration mocks base method.
+func (m *MockPoolStat) AcquireDuration() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireDuration")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// AcquireDuration indicates an expected call of AcquireDuration.
+func (mr *MockPoolStatMockRecorder) AcquireDuration() *MockPoolStatAcquireDurationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireDuration", reflect.TypeOf((*MockPoolStat)(nil).AcquireDuration))
+	return &MockPoolStatAcquireDurationCall{Call: call}
+}
+
+// MockPoolStatAcquireDurationCall wrap *gomock.Call
+type MockPoolStatAcquireDurationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatAcquireDurationCall) Return(arg0 time.Duration) *MockPoolStatAcquireDurationCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatAcquireDurationCall) Do(f func() time.Duration) *MockPoolStatAcquireDurationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatAcquireDurationCall) DoAndReturn(f func() time.Duration) *MockPoolStatAcquireDurationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// AcquiredConns mocks base method.
+func (m *MockPoolStat) AcquiredConns() int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquiredConns")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// AcquiredConns indicates an expected call of AcquiredConns.
+func (mr *MockPoolStatMockRecorder) AcquiredConns() *MockPoolStatAcquiredConnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquiredConns", reflect.TypeOf((*MockPoolStat)(nil).AcquiredConns))
+	return &MockPoolStatAcquiredConnsCall{Call: call}
+}
+
+// MockPoolStatAcquiredConnsCall wrap *gomock.Call
+type MockPoolStatAcquiredConnsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatAcquiredConnsCall) Return(arg0 int32) *MockPoolStatAcquiredConnsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatAcquiredConnsCall) Do(f func() int32) *MockPoolStatAcquiredConnsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatAcquiredConnsCall) DoAndReturn(f func() int32) *MockPoolStatAcquiredConnsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// CanceledAcquireCount mocks base method.
+func (m *MockPoolStat) CanceledAcquireCount() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CanceledAcquireCount")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// CanceledAcquireCount indicates an expected call of CanceledAcquireCount.
+func (mr *MockPoolStatMockRecorder) CanceledAcquireCount() *MockPoolStatCanceledAcquireCountCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanceledAcquireCount", reflect.TypeOf((*MockPoolStat)(nil).CanceledAcquireCount))
+	return &MockPoolStatCanceledAcquireCountCall{Call: call}
+}
+
+// MockPoolStatCanceledAcquireCountCall wrap *gomock.Call
+type MockPoolStatCanceledAcquireCountCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatCanceledAcquireCountCall) Return(arg0 int64) *MockPoolStatCanceledAcquireCountCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatCanceledAcquireCountCall) Do(f func() int64) *MockPoolStatCanceledAcquireCountCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatCanceledAcquireCountCall) DoAndReturn(f func() int64) *MockPoolStatCanceledAcquireCountCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ConstructingConns mocks base method.
+func (m *MockPoolStat) ConstructingConns() int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConstructingConns")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// ConstructingConns indicates an expected call of ConstructingConns.
+func (mr *MockPoolStatMockRecorder) ConstructingConns() *MockPoolStatConstructingConnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConstructingConns", reflect.TypeOf((*MockPoolStat)(nil).ConstructingConns))
+	return &MockPoolStatConstructingConnsCall{Call: call}
+}
+
+// MockPoolStatConstructingConnsCall wrap *gomock.Call
+type MockPoolStatConstructingConnsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatConstructingConnsCall) Return(arg0 int32) *MockPoolStatConstructingConnsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatConstructingConnsCall) Do(f func() int32) *MockPoolStatConstructingConnsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatConstructingConnsCall) DoAndReturn(f func() int32) *MockPoolStatConstructingConnsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// EmptyAcquireCount mocks base method.
+func (m *MockPoolStat) EmptyAcquireCount() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmptyAcquireCount")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// EmptyAcquireCount indicates an expected call of EmptyAcquireCount.
+func (mr *MockPoolStatMockRecorder) EmptyAcquireCount() *MockPoolStatEmptyAcquireCountCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmptyAcquireCount", reflect.TypeOf((*MockPoolStat)(nil).EmptyAcquireCount))
+	return &MockPoolStatEmptyAcquireCountCall{Call: call}
+}
+
+// MockPoolStatEmptyAcquireCountCall wrap *gomock.Call
+type MockPoolStatEmptyAcquireCountCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatEmptyAcquireCountCall) Return(arg0 int64) *MockPoolStatEmptyAcquireCountCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatEmptyAcquireCountCall) Do(f func() int64) *MockPoolStatEmptyAcquireCountCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatEmptyAcquireCountCall) DoAndReturn(f func() int64) *MockPoolStatEmptyAcquireCountCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// IdleConns mocks base method.
+func (m *MockPoolStat) IdleConns() int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdleConns")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// IdleConns indicates an expected call of IdleConns.
+func (mr *MockPoolStatMockRecorder) IdleConns() *MockPoolStatIdleConnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdleConns", reflect.TypeOf((*MockPoolStat)(nil).IdleConns))
+	return &MockPoolStatIdleConnsCall{Call: call}
+}
+
+// MockPoolStatIdleConnsCall wrap *gomock.Call
+type MockPoolStatIdleConnsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatIdleConnsCall) Return(arg0 int32) *MockPoolStatIdleConnsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatIdleConnsCall) Do(f func() int32) *MockPoolStatIdleConnsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatIdleConnsCall) DoAndReturn(f func() int32) *MockPoolStatIdleConnsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MaxConns mocks base method.
+func (m *MockPoolStat) MaxConns() int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxConns")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// MaxConns indicates an expected call of MaxConns.
+func (mr *MockPoolStatMockRecorder) MaxConns() *MockPoolStatMaxConnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxConns", reflect.TypeOf((*MockPoolStat)(nil).MaxConns))
+	return &MockPoolStatMaxConnsCall{Call: call}
+}
+
+// MockPoolStatMaxConnsCall wrap *gomock.Call
+type MockPoolStatMaxConnsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatMaxConnsCall) Return(arg0 int32) *MockPoolStatMaxConnsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatMaxConnsCall) Do(f func() int32) *MockPoolStatMaxConnsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatMaxConnsCall) DoAndReturn(f func() int32) *MockPoolStatMaxConnsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// TotalConns mocks base method.
+func (m *MockPoolStat) TotalConns() int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TotalConns")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// TotalConns indicates an expected call of TotalConns.
+func (mr *MockPoolStatMockRecorder) TotalConns() *MockPoolStatTotalConnsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TotalConns", reflect.TypeOf((*MockPoolStat)(nil).TotalConns))
+	return &MockPoolStatTotalConnsCall{Call: call}
+}
+
+// MockPoolStatTotalConnsCall wrap *gomock.Call
+type MockPoolStatTotalConnsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatTotalConnsCall) Return(arg0 int32) *MockPoolStatTotalConnsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatTotalConnsCall) Do(f func() int32) *MockPoolStatTotalConnsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatTotalConnsCall) DoAndReturn(f func() int32) *MockPoolStatTotalConnsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockPool is a mock of Pool interface.
+type MockPool struct {
+	ctrl     *gomock.Controller
+	recorder *MockPoolMockRecorder
+	isgomock struct{}
+}
+
+// MockPoolMockRecorder is the mock recorder for MockPool.
+type MockPoolMockRecorder struct {
+	mock *MockPool
+}
+
+// NewMockPool creates a new mock instance.
+func NewMockPool(ctrl *gomock.Controller) *MockPool {
+	mock := &MockPool{ctrl: ctrl}
+	mock.recorder = &MockPoolMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPool) EXPECT() *MockPoolMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockPool) Acquire(ctx context.Context) (driver.PoolConn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Acquire", ctx)
+	ret0, _ := ret[0].(driver.PoolConn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockPoolMockRecorder) Acquire(ctx any) *MockPoolAcquireCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockPool)(nil).Acquire), ctx)
+	return &MockPoolAcquireCall{Call: call}
+}
+
+// MockPoolAcquireCall wrap *gomock.Call
+type MockPoolAcquireCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolAcquireCall) Return(arg0 driver.PoolConn, arg1 error) *MockPoolAcquireCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolAcquireCall) Do(f func(context.Context) (driver.PoolConn, error)) *MockPoolAcquireCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolAcquireCall) DoAndReturn(f func(context.Context) (driver.PoolConn, error)) *MockPoolAcquireCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Close mocks base method.
+func (m *MockPool) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockPoolMockRecorder) Close() *MockPoolCloseCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPool)(nil).Close))
+	return &MockPoolCloseCall{Call: call}
+}
+
+// MockPoolCloseCall wrap *gomock.Call
+type MockPoolCloseCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolCloseCall) Return() *MockPoolCloseCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolCloseCall) Do(f func()) *MockPoolCloseCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolCloseCall) DoAndReturn(f func()) *MockPoolCloseCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Stat mocks base method.
+func (m *MockPool) Stat() driver.PoolStat {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat")
+	ret0, _ := ret[0].(driver.PoolStat)
+	return ret0
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockPoolMockRecorder) Stat() *MockPoolStatCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockPool)(nil).Stat))
+	return &MockPoolStatCall{Call: call}
+}
+
+// MockPoolStatCall wrap *gomock.Call
+type MockPoolStatCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPoolStatCall) Return(arg0 driver.PoolStat) *MockPoolStatCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPoolStatCall) Do(f func() driver.PoolStat) *MockPoolStatCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPoolStatCall) DoAndReturn(f func() driver.PoolStat) *MockPoolStatCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
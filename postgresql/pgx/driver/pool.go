@@ -0,0 +1,33 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewPool makes the pgx pool a Pool, which it is not as is, because
+// it returns concrete types.
+func NewPool(pool *pgxpool.Pool) Pool {
+	return pgxPool{pool: pool}
+}
+
+type pgxPool struct {
+	pool *pgxpool.Pool
+}
+
+func (p pgxPool) Acquire(ctx context.Context) (PoolConn, error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p pgxPool) Stat() PoolStat {
+	return p.pool.Stat()
+}
+
+func (p pgxPool) Close() {
+	p.pool.Close()
+}
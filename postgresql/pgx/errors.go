@@ -0,0 +1,232 @@
+package pgxadapt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/errs"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrorTranslator maps a PostgreSQL error raised by the query
+// to an adapter or a domain error.
+//
+// Translate returns nil if it has no mapping for the error.
+// The translated error is joined with the driver error,
+// so the latter stays reachable through errors.As.
+type ErrorTranslator interface {
+	Translate(query string, pgErr *pgconn.PgError) error
+}
+
+type ErrorTranslatorFunc func(query string, pgErr *pgconn.PgError) error
+
+func (f ErrorTranslatorFunc) Translate(
+	query string,
+	pgErr *pgconn.PgError,
+) error {
+
+	return f(query, pgErr)
+}
+
+// DefaultErrorTranslator maps SQLSTATE codes to the adapter errors.
+var DefaultErrorTranslator ErrorTranslator = ErrorTranslatorFunc(
+	translatePgError,
+)
+
+// ChainErrorTranslators returns a translator that tries the translators
+// in order and returns the first non-nil error.
+//
+// Put DefaultErrorTranslator last to keep the built-in mapping
+// for the codes the custom translators do not know.
+func ChainErrorTranslators(translators ...ErrorTranslator) ErrorTranslator {
+	return ErrorTranslatorFunc(
+		func(query string, pgErr *pgconn.PgError) error {
+			for _, translator := range translators {
+				if err := translator.Translate(query, pgErr); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// translateError maps a driver error to the matching adapter error.
+// Every error returned by the driver should pass through it, so that
+// the same failure yields the same adapter error on every code path.
+// Errors without a mapping are wrapped only to be classified.
+func translateError(opts *options, query string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return errs.New(adapter.ErrNoRows, err)
+	case errors.Is(err, pgx.ErrTooManyRows):
+		return errs.New(adapter.ErrTooManyRows, err)
+	case errors.Is(err, pgx.ErrTxClosed):
+		return errs.New(adapter.ErrTxDone, err)
+	case errors.Is(err, pgx.ErrTxCommitRollback):
+		return errs.New(adapter.ErrTxRolledBack, err)
+	case errors.Is(err, context.Canceled):
+		return errs.New(adapter.ErrQueryCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err):
+		return errs.New(adapter.ErrQueryTimeout, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return errs.Wrap(err)
+	}
+
+	translated := opts.translator.Translate(query, pgErr)
+	if translated != nil {
+		err = errs.New(translated, err)
+	} else {
+		err = errs.Wrap(err)
+	}
+
+	if opts.development {
+		err = newPositionError(err, query, pgErr)
+	}
+	return err
+}
+
+// PositionError annotates an error with the place in the query
+// the server has reported it at. It is only returned in development mode.
+type PositionError struct {
+	Err error
+	// Line and Column are 1-based.
+	Line   int
+	Column int
+	// Excerpt is the offending line of the query with a caret under
+	// the reported position.
+	Excerpt string
+}
+
+func (e *PositionError) Error() string {
+	return fmt.Sprintf(
+		"%s at line %d, column %d:\n%s",
+		e.Err.Error(),
+		e.Line,
+		e.Column,
+		e.Excerpt,
+	)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// newPositionError returns err unchanged if the server
+// has not reported a position.
+func newPositionError(err error, query string, pgErr *pgconn.PgError) error {
+	pos := int(pgErr.Position)
+	if pos == 0 && pgErr.InternalPosition != 0 {
+		pos, query = int(pgErr.InternalPosition), pgErr.InternalQuery
+	}
+
+	// Position counts characters, not bytes, starting from 1.
+	text := []rune(query)
+	if pos < 1 || pos > len(text)+1 {
+		return err
+	}
+
+	lineStart, line := 0, 1
+	for i, r := range text[:pos-1] {
+		if r == '\n' {
+			lineStart, line = i+1, line+1
+		}
+	}
+
+	lineEnd := lineStart
+	for lineEnd < len(text) && text[lineEnd] != '\n' {
+		lineEnd++
+	}
+
+	// Keep tabs in the padding, so that the caret stays aligned.
+	padding := make([]rune, 0, pos-1-lineStart)
+	for _, r := range text[lineStart : pos-1] {
+		if r != '\t' {
+			r = ' '
+		}
+		padding = append(padding, r)
+	}
+
+	prefix := strconv.Itoa(line) + " | "
+	return &PositionError{
+		Err:    err,
+		Line:   line,
+		Column: pos - lineStart,
+		Excerpt: prefix + string(text[lineStart:lineEnd]) + "\n" +
+			strings.Repeat(" ", len(prefix)) + string(padding) + "^",
+	}
+}
+
+func translatePgError(_ string, pgErr *pgconn.PgError) error {
+	switch pgErr.Code {
+	case pgerrcode.CheckViolation:
+		return newConstraintError(adapter.ErrCheckViolation, pgErr)
+	case pgerrcode.UniqueViolation:
+		return newConstraintError(adapter.ErrUniqueViolation, pgErr)
+	case pgerrcode.NotNullViolation:
+		return newConstraintError(adapter.ErrNotNullViolation, pgErr)
+	case pgerrcode.ForeignKeyViolation:
+		return newConstraintError(adapter.ErrForeignKeyViolation, pgErr)
+	case pgerrcode.ExclusionViolation:
+		return newConstraintError(adapter.ErrExclusionViolation, pgErr)
+
+	case pgerrcode.SerializationFailure:
+		return adapter.ErrSerializationFailure
+	case pgerrcode.DeadlockDetected:
+		return adapter.ErrDeadlockDetected
+	case pgerrcode.LockNotAvailable:
+		return adapter.ErrLockNotAvailable
+	case pgerrcode.QueryCanceled:
+		if errs.IsStatementTimeout(pgErr) {
+			return adapter.ErrQueryTimeout
+		}
+		return adapter.ErrQueryCanceled
+
+	case pgerrcode.StringDataRightTruncationDataException:
+		return adapter.ErrStringDataRightTruncation
+	case pgerrcode.NumericValueOutOfRange:
+		return adapter.ErrNumericValueOutOfRange
+	case pgerrcode.InvalidTextRepresentation:
+		return adapter.ErrInvalidTextRepresentation
+
+	case pgerrcode.ReadOnlySQLTransaction:
+		return adapter.ErrReadOnlyTransaction
+	case pgerrcode.InsufficientPrivilege:
+		return adapter.ErrInsufficientPrivilege
+	case pgerrcode.UndefinedTable:
+		return adapter.ErrUndefinedTable
+	case pgerrcode.UndefinedColumn:
+		return adapter.ErrUndefinedColumn
+
+	case pgerrcode.InvalidSavepointSpecification:
+		return adapter.ErrUnknownSavepoint
+	}
+
+	return nil
+}
+
+func newConstraintError(
+	violation error,
+	pgErr *pgconn.PgError,
+) *adapter.ConstraintError {
+
+	return &adapter.ConstraintError{
+		Err:        violation,
+		Code:       pgErr.Code,
+		Constraint: pgErr.ConstraintName,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		Detail:     pgErr.Detail,
+	}
+}
@@ -0,0 +1,393 @@
+package pgxadapt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		Code       string
+		Message    string
+		Expected   error
+		Constraint bool
+	}{
+		"check_violation": {
+			Code:       pgerrcode.CheckViolation,
+			Expected:   adapter.ErrCheckViolation,
+			Constraint: true,
+		},
+		"unique_violation": {
+			Code:       pgerrcode.UniqueViolation,
+			Expected:   adapter.ErrUniqueViolation,
+			Constraint: true,
+		},
+		"not_null_violation": {
+			Code:       pgerrcode.NotNullViolation,
+			Expected:   adapter.ErrNotNullViolation,
+			Constraint: true,
+		},
+		"foreign_key_violation": {
+			Code:       pgerrcode.ForeignKeyViolation,
+			Expected:   adapter.ErrForeignKeyViolation,
+			Constraint: true,
+		},
+		"exclusion_violation": {
+			Code:       pgerrcode.ExclusionViolation,
+			Expected:   adapter.ErrExclusionViolation,
+			Constraint: true,
+		},
+		"serialization_failure": {
+			Code:     pgerrcode.SerializationFailure,
+			Expected: adapter.ErrSerializationFailure,
+		},
+		"deadlock_detected": {
+			Code:     pgerrcode.DeadlockDetected,
+			Expected: adapter.ErrDeadlockDetected,
+		},
+		"lock_not_available": {
+			Code:     pgerrcode.LockNotAvailable,
+			Expected: adapter.ErrLockNotAvailable,
+		},
+		"query_canceled": {
+			Code:     pgerrcode.QueryCanceled,
+			Expected: adapter.ErrQueryCanceled,
+		},
+		"statement_timeout": {
+			Code:     pgerrcode.QueryCanceled,
+			Message:  "canceling statement due to statement timeout",
+			Expected: adapter.ErrQueryTimeout,
+		},
+		"string_data_right_truncation": {
+			Code:     pgerrcode.StringDataRightTruncationDataException,
+			Expected: adapter.ErrStringDataRightTruncation,
+		},
+		"numeric_value_out_of_range": {
+			Code:     pgerrcode.NumericValueOutOfRange,
+			Expected: adapter.ErrNumericValueOutOfRange,
+		},
+		"invalid_text_representation": {
+			Code:     pgerrcode.InvalidTextRepresentation,
+			Expected: adapter.ErrInvalidTextRepresentation,
+		},
+		"read_only_sql_transaction": {
+			Code:     pgerrcode.ReadOnlySQLTransaction,
+			Expected: adapter.ErrReadOnlyTransaction,
+		},
+		"insufficient_privilege": {
+			Code:     pgerrcode.InsufficientPrivilege,
+			Expected: adapter.ErrInsufficientPrivilege,
+		},
+		"undefined_table": {
+			Code:     pgerrcode.UndefinedTable,
+			Expected: adapter.ErrUndefinedTable,
+		},
+		"undefined_column": {
+			Code:     pgerrcode.UndefinedColumn,
+			Expected: adapter.ErrUndefinedColumn,
+		},
+		"invalid_savepoint_specification": {
+			Code:     pgerrcode.InvalidSavepointSpecification,
+			Expected: adapter.ErrUnknownSavepoint,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			pgErr := &pgconn.PgError{
+				Code:    testCase.Code,
+				Message: testCase.Message,
+			}
+
+			err := translateError(newOptions(nil), "", pgErr)
+			require.ErrorIs(t, err, testCase.Expected)
+			require.ErrorIs(t, err, pgErr)
+
+			var constraintErr *adapter.ConstraintError
+			require.Equal(
+				t,
+				testCase.Constraint,
+				errors.As(err, &constraintErr),
+			)
+		})
+	}
+
+	t.Run("no_rows", func(t *testing.T) {
+		t.Parallel()
+
+		err := translateError(newOptions(nil), "", pgx.ErrNoRows)
+		require.EqualError(t, err, adapter.ErrNoRows.Error())
+		require.ErrorIs(t, err, adapter.ErrNoRows)
+		require.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("too_many_rows", func(t *testing.T) {
+		t.Parallel()
+
+		err := translateError(newOptions(nil), "", pgx.ErrTooManyRows)
+		require.EqualError(t, err, adapter.ErrTooManyRows.Error())
+		require.ErrorIs(t, err, adapter.ErrTooManyRows)
+		require.ErrorIs(t, err, pgx.ErrTooManyRows)
+	})
+
+	t.Run("tx_closed", func(t *testing.T) {
+		t.Parallel()
+
+		err := translateError(newOptions(nil), "", pgx.ErrTxClosed)
+		require.ErrorIs(t, err, adapter.ErrTxDone)
+		require.ErrorIs(t, err, pgx.ErrTxClosed)
+	})
+
+	t.Run("tx_commit_rollback", func(t *testing.T) {
+		t.Parallel()
+
+		err := translateError(newOptions(nil), "", pgx.ErrTxCommitRollback)
+		require.ErrorIs(t, err, adapter.ErrTxRolledBack)
+		require.ErrorIs(t, err, pgx.ErrTxCommitRollback)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+
+		require.NoError(t, translateError(newOptions(nil), "", nil))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Parallel()
+
+		errInsufficientFunds := errors.New("insufficient funds")
+
+		const query = "SELECT withdraw($1, $2)"
+
+		opts := newOptions([]Option{WithErrorTranslator(ChainErrorTranslators(
+			ErrorTranslatorFunc(func(q string, pgErr *pgconn.PgError) error {
+				require.Equal(t, query, q)
+				if pgErr.Code == "U0001" {
+					return errInsufficientFunds
+				}
+				return nil
+			}),
+			DefaultErrorTranslator,
+		))})
+
+		pgErr := &pgconn.PgError{Code: "U0001"}
+		err := translateError(opts, query, pgErr)
+		require.EqualError(t, err, errInsufficientFunds.Error())
+		require.ErrorIs(t, err, errInsufficientFunds)
+		require.ErrorIs(t, err, pgErr)
+
+		pgErr = &pgconn.PgError{Code: pgerrcode.UniqueViolation}
+		err = translateError(opts, query, pgErr)
+		require.ErrorIs(t, err, adapter.ErrUniqueViolation)
+	})
+
+	t.Run("unmapped", func(t *testing.T) {
+		t.Parallel()
+
+		pgErr := &pgconn.PgError{
+			Code:    pgerrcode.SyntaxError,
+			Message: "syntax error",
+		}
+		err := translateError(newOptions(nil), "", pgErr)
+		require.EqualError(t, err, pgErr.Error())
+		require.ErrorIs(t, err, pgErr)
+
+		cause := errors.New("cause")
+		err = translateError(newOptions(nil), "", cause)
+		require.EqualError(t, err, cause.Error())
+		require.ErrorIs(t, err, cause)
+	})
+}
+
+func TestErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		Err        error
+		Retryable  bool
+		Transient  bool
+		Connection bool
+		Timeout    bool
+	}{
+		"serialization_failure": {
+			Err:       &pgconn.PgError{Code: pgerrcode.SerializationFailure},
+			Retryable: true,
+			Transient: true,
+		},
+		"deadlock_detected": {
+			Err:       &pgconn.PgError{Code: pgerrcode.DeadlockDetected},
+			Retryable: true,
+			Transient: true,
+		},
+		"too_many_connections": {
+			Err:       &pgconn.PgError{Code: pgerrcode.TooManyConnections},
+			Retryable: true,
+			Transient: true,
+		},
+		"admin_shutdown": {
+			Err:       &pgconn.PgError{Code: pgerrcode.AdminShutdown},
+			Transient: true,
+		},
+		"connection_failure": {
+			Err:        &pgconn.PgError{Code: pgerrcode.ConnectionFailure},
+			Transient:  true,
+			Connection: true,
+		},
+		"query_canceled": {
+			Err:       &pgconn.PgError{Code: pgerrcode.QueryCanceled},
+			Transient: true,
+		},
+		"statement_timeout": {
+			Err: &pgconn.PgError{
+				Code:    pgerrcode.QueryCanceled,
+				Message: "canceling statement due to statement timeout",
+			},
+			Transient: true,
+			Timeout:   true,
+		},
+		"deadline_exceeded": {
+			Err:       context.DeadlineExceeded,
+			Transient: true,
+			Timeout:   true,
+		},
+		"canceled": {
+			Err: context.Canceled,
+		},
+		"net_error": {
+			Err:        &net.OpError{Op: "read", Err: errors.New("reset")},
+			Transient:  true,
+			Connection: true,
+		},
+		"unique_violation": {
+			Err: &pgconn.PgError{Code: pgerrcode.UniqueViolation},
+		},
+		"other": {
+			Err: errors.New("other"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := translateError(newOptions(nil), "", testCase.Err)
+			require.Equal(t, testCase.Retryable, adapter.IsRetryable(err))
+			require.Equal(t, testCase.Transient, adapter.IsTransient(err))
+			require.Equal(
+				t,
+				testCase.Connection,
+				adapter.IsConnectionError(err),
+			)
+			require.Equal(t, testCase.Timeout, adapter.IsTimeout(err))
+		})
+	}
+}
+
+func TestPositionError(t *testing.T) {
+	t.Parallel()
+
+	const query = "SELECT id\nFROM users\nWHERE\temial = $1"
+
+	testCases := map[string]struct {
+		PgErr    *pgconn.PgError
+		Query    string
+		Expected *PositionError
+	}{
+		"position": {
+			PgErr: &pgconn.PgError{
+				Code:     pgerrcode.UndefinedColumn,
+				Position: 28,
+			},
+			Query: query,
+			Expected: &PositionError{
+				Line:    3,
+				Column:  7,
+				Excerpt: "3 | WHERE\temial = $1\n         \t^",
+			},
+		},
+		"internal_position": {
+			PgErr: &pgconn.PgError{
+				Code:             pgerrcode.SyntaxError,
+				InternalPosition: 8,
+				InternalQuery:    "SELECT FROM WHERE",
+			},
+			Query: query,
+			Expected: &PositionError{
+				Line:    1,
+				Column:  8,
+				Excerpt: "1 | SELECT FROM WHERE\n           ^",
+			},
+		},
+		"multibyte": {
+			PgErr: &pgconn.PgError{
+				Code:     pgerrcode.SyntaxError,
+				Position: 12,
+			},
+			Query: "SELECT 'ü', FROM",
+			Expected: &PositionError{
+				Line:    1,
+				Column:  12,
+				Excerpt: "1 | SELECT 'ü', FROM\n               ^",
+			},
+		},
+		"no_position": {
+			PgErr: &pgconn.PgError{Code: pgerrcode.SyntaxError},
+			Query: query,
+		},
+		"out_of_range": {
+			PgErr: &pgconn.PgError{
+				Code:     pgerrcode.SyntaxError,
+				Position: 100,
+			},
+			Query: query,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := newOptions([]Option{WithDevelopmentMode()})
+			err := translateError(opts, testCase.Query, testCase.PgErr)
+			require.ErrorIs(t, err, testCase.PgErr)
+
+			var posErr *PositionError
+			if testCase.Expected == nil {
+				require.False(t, errors.As(err, &posErr))
+				return
+			}
+
+			require.ErrorAs(t, err, &posErr)
+			require.Equal(t, testCase.Expected.Line, posErr.Line)
+			require.Equal(t, testCase.Expected.Column, posErr.Column)
+			require.Equal(t, testCase.Expected.Excerpt, posErr.Excerpt)
+			require.Contains(t, err.Error(), testCase.Expected.Excerpt)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		pgErr := &pgconn.PgError{
+			Code:     pgerrcode.UndefinedColumn,
+			Position: 28,
+		}
+		err := translateError(newOptions(nil), query, pgErr)
+		require.ErrorIs(t, err, adapter.ErrUndefinedColumn)
+
+		var posErr *PositionError
+		require.False(t, errors.As(err, &posErr))
+	})
+}
@@ -1,21 +1,124 @@
 package errs
 
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error pairs an adapter error with the driver error that caused it.
+// Both of them are reachable through errors.Is and errors.As.
+//
+// It also classifies the driver error for the predicates
+// of the adapter package, such as adapter.IsRetryable.
 type Error struct {
-	msg string
-	err error
+	err   error
+	cause error
 }
 
-func New(message string, err error) Error {
+func New(err, cause error) Error {
 	return Error{
-		msg: message,
-		err: err,
+		err:   err,
+		cause: cause,
 	}
 }
 
+// Wrap returns an Error that has no adapter error,
+// so that the driver error can still be classified.
+func Wrap(cause error) Error {
+	return Error{
+		cause: cause,
+	}
+}
+
+// Error returns the message of the adapter error,
+// or the message of the driver error if there is none.
 func (e Error) Error() string {
-	return e.msg
+	if e.err == nil {
+		return e.cause.Error()
+	}
+	return e.err.Error()
+}
+
+func (e Error) Unwrap() []error {
+	if e.err == nil {
+		return []error{e.cause}
+	}
+	return []error{e.err, e.cause}
+}
+
+// Retryable reports whether the failed operation can be run again:
+// either it has never reached the server, or the server rolled it back
+// because of a concurrent transaction or a lack of resources.
+func (e Error) Retryable() bool {
+	if pgconn.SafeToRetry(e.cause) {
+		return true
+	}
+
+	code := e.code()
+	return pgerrcode.IsTransactionRollback(code) ||
+		pgerrcode.IsInsufficientResources(code) ||
+		code == pgerrcode.CannotConnectNow
 }
 
-func (e Error) Unwrap() error {
-	return e.err
+// Transient reports whether the failure is likely to go away by itself.
+func (e Error) Transient() bool {
+	code := e.code()
+	return pgerrcode.IsConnectionException(code) ||
+		pgerrcode.IsTransactionRollback(code) ||
+		pgerrcode.IsInsufficientResources(code) ||
+		pgerrcode.IsOperatorIntervention(code) ||
+		e.ConnectionError() ||
+		e.Timeout()
+}
+
+// ConnectionError reports whether the connection to the server
+// failed or was lost.
+func (e Error) ConnectionError() bool {
+	if pgerrcode.IsConnectionException(e.code()) {
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	var opErr *net.OpError
+	return errors.As(e.cause, &connectErr) ||
+		errors.As(e.cause, &opErr) ||
+		errors.Is(e.cause, io.ErrUnexpectedEOF)
+}
+
+// Timeout reports whether the operation was interrupted by a deadline,
+// either of the context or of the statement_timeout setting.
+func (e Error) Timeout() bool {
+	if errors.Is(e.cause, context.Canceled) {
+		return false
+	}
+
+	return pgconn.Timeout(e.cause) ||
+		errors.Is(e.cause, context.DeadlineExceeded) ||
+		IsStatementTimeout(e.cause)
+}
+
+// IsStatementTimeout reports whether err is a statement canceled because
+// of the statement_timeout setting rather than by a cancel request.
+//
+// The server only tells them apart by the message, which is not
+// translated unless lc_messages is set.
+func IsStatementTimeout(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		pgErr.Code == pgerrcode.QueryCanceled &&
+		strings.Contains(pgErr.Message, "statement timeout")
+}
+
+func (e Error) code() string {
+	var pgErr *pgconn.PgError
+	if errors.As(e.cause, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
 }
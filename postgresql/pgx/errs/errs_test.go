@@ -0,0 +1,43 @@
+package errs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("sentinel")
+	cause := &pgconn.PgError{Code: "23505"}
+
+	err := error(New(sentinel, cause))
+	require.EqualError(t, err, sentinel.Error())
+	require.ErrorIs(t, err, sentinel)
+	require.ErrorIs(t, err, cause)
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, cause, pgErr)
+}
+
+func TestIsStatementTimeout(t *testing.T) {
+	t.Parallel()
+
+	timeout := &pgconn.PgError{
+		Code:    "57014",
+		Message: "canceling statement due to statement timeout",
+	}
+	canceled := &pgconn.PgError{
+		Code:    "57014",
+		Message: "canceling statement due to user request",
+	}
+
+	require.True(t, IsStatementTimeout(timeout))
+	require.True(t, New(errors.New("timeout"), timeout).Timeout())
+	require.False(t, IsStatementTimeout(canceled))
+	require.False(t, New(errors.New("canceled"), canceled).Timeout())
+}
@@ -0,0 +1,91 @@
+package pgxadapt
+
+import (
+	"context"
+	"sync"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+)
+
+// GuardMode is what a call does when the connection is already in use
+// by another goroutine. See WithConcurrencyGuard.
+type GuardMode int
+
+const (
+	// GuardSerialize waits for the connection to be released,
+	// or for the context to be done.
+	GuardSerialize GuardMode = iota + 1
+	// GuardFailFast fails with adapter.ErrConnBusy right away.
+	GuardFailFast
+)
+
+// connGuard lets one call at a time use the connection.
+// The nil guard lets every call through.
+type connGuard struct {
+	mode GuardMode
+	// sem holds a value while the connection is in use.
+	sem chan struct{}
+}
+
+// newConnGuard returns nil if the mode is zero.
+func newConnGuard(mode GuardMode) *connGuard {
+	if mode == 0 {
+		return nil
+	}
+	return &connGuard{
+		mode: mode,
+		sem:  make(chan struct{}, 1),
+	}
+}
+
+// acquire returns the function that releases the connection.
+// The function can be called many times.
+func (g *connGuard) acquire(ctx context.Context) (func(), error) {
+	if g == nil {
+		return func() {}, nil
+	}
+
+	if g.mode == GuardFailFast {
+		release, ok := g.tryAcquire()
+		if !ok {
+			return nil, adapter.ErrConnBusy
+		}
+		return release, nil
+	}
+	return g.wait(ctx)
+}
+
+// tryAcquire acquires the connection regardless of the mode,
+// unless it is already in use.
+func (g *connGuard) tryAcquire() (func(), bool) {
+	if g == nil {
+		return func() {}, true
+	}
+
+	select {
+	case g.sem <- struct{}{}:
+		return g.releaseFunc(), true
+	default:
+		return nil, false
+	}
+}
+
+// wait acquires the connection regardless of the mode.
+func (g *connGuard) wait(ctx context.Context) (func(), error) {
+	if g == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case g.sem <- struct{}{}:
+		return g.releaseFunc(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *connGuard) releaseFunc() func() {
+	return sync.OnceFunc(func() {
+		<-g.sem
+	})
+}
@@ -0,0 +1,286 @@
+package pgxadapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	mock_driver "github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver/mock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConn_ConcurrencyGuard(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fail_fast", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRows := mock_driver.NewMockRows(ctrl)
+		mockRows.
+			EXPECT().
+			Close()
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Query(gomock.Any(), "SELECT 1").
+			Return(pgxRows{driverRows: mockRows}, nil)
+		mockConn.
+			EXPECT().
+			Exec(gomock.Any(), "SELECT 2").
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		conn := NewConn(
+			mockConn,
+			mockTracer,
+			WithConcurrencyGuard(GuardFailFast),
+		)
+
+		rows, err := conn.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+
+		_, err = conn.Exec(ctx, "SELECT 2")
+		require.ErrorIs(t, err, adapter.ErrConnBusy)
+
+		require.NoError(t, rows.Close())
+
+		_, err = conn.Exec(ctx, "SELECT 2")
+		require.NoError(t, err)
+	})
+
+	t.Run("serialize", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any())
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{driverTx: mockTx}, nil)
+		mockConn.
+			EXPECT().
+			Exec(gomock.Any(), "SELECT 1").
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		conn := NewConn(
+			mockConn,
+			mockTracer,
+			WithConcurrencyGuard(GuardSerialize),
+		)
+
+		tx, err := conn.Begin(ctx)
+		require.NoError(t, err)
+
+		// The transaction holds the connection until it is done.
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err = conn.Exec(timeoutCtx, "SELECT 1")
+		require.ErrorIs(t, err, adapter.ErrQueryTimeout)
+
+		done := make(chan error)
+		go func() {
+			_, err := conn.Exec(ctx, "SELECT 1")
+			done <- err
+		}()
+
+		require.NoError(t, tx.Commit(ctx))
+		require.NoError(t, <-done)
+	})
+
+	t.Run("tx", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRow := mock_driver.NewMockRow(ctrl)
+		mockRow.
+			EXPECT().
+			Scan(gomock.Any())
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			QueryRow(gomock.Any(), "SELECT 1").
+			Return(mockRow)
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any())
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer, WithConcurrencyGuard(GuardFailFast))
+
+		// The row holds the connection until it is scanned.
+		row := tx.QueryRow(ctx, "SELECT 1")
+		require.ErrorIs(t, tx.Commit(ctx), adapter.ErrConnBusy)
+
+		require.NoError(t, row.Scan())
+		require.NoError(t, tx.Commit(ctx))
+	})
+
+	t.Run("pool_tx", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRow := mock_driver.NewMockRow(ctrl)
+		mockRow.
+			EXPECT().
+			Scan(gomock.Any())
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			QueryRow(gomock.Any(), "SELECT 1").
+			Return(mockRow)
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any())
+
+		mockConn := mock_driver.NewMockPoolConn(ctrl)
+		mockConn.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{driverTx: mockTx}, nil)
+		mockConn.
+			EXPECT().
+			Release()
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Acquire(gomock.Any()).
+			Return(mockConn, nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		pool := NewPool(
+			mockPool,
+			mockTracer,
+			WithConcurrencyGuard(GuardFailFast),
+		)
+
+		// A transaction begun from the pool may be shared,
+		// so it is guarded like the one of a connection.
+		tx, err := pool.Begin(ctx)
+		require.NoError(t, err)
+
+		row := tx.QueryRow(ctx, "SELECT 1")
+		require.ErrorIs(t, tx.Commit(ctx), adapter.ErrConnBusy)
+
+		require.NoError(t, row.Scan())
+		require.NoError(t, tx.Commit(ctx))
+	})
+
+	// Rollback and Close wait for the rows instead of failing,
+	// so that nothing is left open.
+	t.Run("fail_fast_cleanup", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTxRows := mock_driver.NewMockRows(ctrl)
+		mockRows := mock_driver.NewMockRows(ctrl)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Query(gomock.Any(), "SELECT 1").
+			Return(pgxRows{driverRows: mockTxRows}, nil)
+
+		mockConn := mock_driver.NewMockConn(ctrl)
+		mockConn.
+			EXPECT().
+			Query(gomock.Any(), "SELECT 2").
+			Return(pgxRows{driverRows: mockRows}, nil)
+
+		gomock.InOrder(
+			mockTxRows.
+				EXPECT().
+				Close(),
+			mockTx.
+				EXPECT().
+				Rollback(gomock.Any()),
+		)
+		gomock.InOrder(
+			mockRows.
+				EXPECT().
+				Close(),
+			mockConn.
+				EXPECT().
+				Close(),
+		)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer, WithConcurrencyGuard(GuardFailFast))
+		conn := NewConn(
+			mockConn,
+			mockTracer,
+			WithConcurrencyGuard(GuardFailFast),
+		)
+
+		txRows, err := tx.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+		rows, err := conn.Query(ctx, "SELECT 2")
+		require.NoError(t, err)
+
+		rolledBack := make(chan error)
+		go func() {
+			rolledBack <- tx.Rollback(ctx)
+		}()
+		closed := make(chan error)
+		go func() {
+			closed <- conn.Close()
+		}()
+
+		select {
+		case <-rolledBack:
+			t.Fatal("the transaction has been rolled back with the rows open")
+		case <-closed:
+			t.Fatal("the connection has been closed with the rows open")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		require.NoError(t, txRows.Close())
+		require.NoError(t, <-rolledBack)
+		require.NoError(t, rows.Close())
+		require.NoError(t, <-closed)
+	})
+}
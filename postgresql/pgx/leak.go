@@ -0,0 +1,146 @@
+package pgxadapt
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+)
+
+const (
+	LeakKindTx   = "transaction"
+	LeakKindRows = "rows"
+)
+
+// Leak is a transaction that has not been finished,
+// or rows that have not been closed.
+type Leak struct {
+	// Kind is either LeakKindTx or LeakKindRows.
+	Kind string
+	// Query is empty for transactions.
+	Query string
+	// Stack is the stack trace of the goroutine that has opened it.
+	Stack string
+	Age   time.Duration
+}
+
+// leakTracker keeps the open transactions and rows of a connection.
+// The nil tracker tracks nothing.
+type leakTracker struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	nextID  uint64
+	entries map[uint64]*leakEntry
+}
+
+type leakEntry struct {
+	kind    string
+	query   string
+	stack   string
+	created time.Time
+	tracer  trace.Logger
+	// timer is nil if the tracker has no maximum age.
+	timer *time.Timer
+}
+
+func newLeakTracker(maxAge time.Duration) *leakTracker {
+	return &leakTracker{
+		maxAge:  maxAge,
+		entries: make(map[uint64]*leakEntry),
+	}
+}
+
+// track records the stack of the caller and returns the function
+// that must be called once the transaction or the rows are done with.
+// The function can be called many times.
+func (t *leakTracker) track(
+	tracer trace.Logger,
+	kind string,
+	query string,
+) func() {
+
+	if t == nil {
+		return func() {}
+	}
+
+	entry := &leakEntry{
+		kind:    kind,
+		query:   query,
+		stack:   string(debug.Stack()),
+		created: time.Now(),
+		tracer:  tracer,
+	}
+
+	t.mu.Lock()
+	id := t.nextID
+	t.nextID++
+	t.entries[id] = entry
+	if t.maxAge > 0 {
+		entry.timer = time.AfterFunc(t.maxAge, func() {
+			entry.report()
+		})
+	}
+	t.mu.Unlock()
+
+	return sync.OnceFunc(func() {
+		t.mu.Lock()
+		delete(t.entries, id)
+		t.mu.Unlock()
+
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+	})
+}
+
+// report logs every open entry.
+func (t *leakTracker) report() {
+	for _, entry := range t.open() {
+		entry.report()
+	}
+}
+
+// leaks returns nil if nothing is open.
+func (t *leakTracker) leaks() []Leak {
+	var leaks []Leak
+	for _, entry := range t.open() {
+		leaks = append(leaks, entry.leak())
+	}
+	return leaks
+}
+
+func (t *leakTracker) open() []*leakEntry {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]*leakEntry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (e *leakEntry) leak() Leak {
+	return Leak{
+		Kind:  e.kind,
+		Query: e.query,
+		Stack: e.stack,
+		Age:   time.Since(e.created),
+	}
+}
+
+func (e *leakEntry) report() {
+	leak := e.leak()
+	e.tracer.Log(trace.ErrorLevel, "found a leak", map[string]any{
+		trace.QueryKey:    leak.Query,
+		trace.StackKey:    leak.Stack,
+		trace.DurationKey: leak.Age,
+		trace.LeakKey:     leak.Kind,
+	})
+}
@@ -0,0 +1,119 @@
+package pgxadapt
+
+import "time"
+
+type Option func(o *options)
+
+type options struct {
+	translator    ErrorTranslator
+	development   bool
+	eagerQueryRow bool
+	// leaks is nil unless leak detection is enabled.
+	leaks *leakTracker
+
+	txMaxLifetime time.Duration
+	txMaxIdle     time.Duration
+
+	guardMode GuardMode
+
+	resetQuery string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		translator: DefaultErrorTranslator,
+		resetQuery: defaultResetQuery,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithErrorTranslator replaces DefaultErrorTranslator.
+// Use ChainErrorTranslators to keep the built-in mapping.
+func WithErrorTranslator(translator ErrorTranslator) Option {
+	return func(o *options) {
+		o.translator = translator
+	}
+}
+
+// WithDevelopmentMode annotates errors that the server reports
+// at a position in the query, such as syntax errors, with the line,
+// the column and an excerpt of the query. See PositionError.
+func WithDevelopmentMode() Option {
+	return func(o *options) {
+		o.development = true
+	}
+}
+
+// WithEagerQueryRow makes QueryRow run the query and read the row
+// before it returns, instead of when the row is scanned.
+// The execution errors are then reported by both Row.Err and Row.Scan,
+// and the traced duration covers the whole round trip.
+func WithEagerQueryRow() Option {
+	return func(o *options) {
+		o.eagerQueryRow = true
+	}
+}
+
+// WithLeakDetection records where every transaction and rows are opened,
+// and reports the ones that are still open after maxAge, or when
+// the connection is closed. Zero maxAge only reports them at Close.
+// See Conn.LeakReport.
+//
+// Nested transactions are not tracked, since they are finished
+// together with their parents.
+func WithLeakDetection(maxAge time.Duration) Option {
+	return func(o *options) {
+		o.leaks = newLeakTracker(maxAge)
+	}
+}
+
+// WithTxMaxLifetime rolls back the transactions that are still open
+// after d, in the background. Using such a transaction afterwards fails
+// with adapter.ErrTxExpired.
+func WithTxMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		o.txMaxLifetime = d
+	}
+}
+
+// WithTxMaxIdle rolls back the transactions that have not run a query
+// for d, in the background. Using such a transaction afterwards fails
+// with adapter.ErrTxExpired. The transactions with open rows, or a row
+// that is not scanned yet, are not idle.
+func WithTxMaxIdle(d time.Duration) Option {
+	return func(o *options) {
+		o.txMaxIdle = d
+	}
+}
+
+// WithConcurrencyGuard lets only one goroutine at a time use
+// the connection, which the driver does not allow, and makes
+// the others wait or fail depending on the mode.
+//
+// Rows hold the connection until they are closed, a row returned
+// by QueryRow until it is scanned, and a transaction until it is done.
+// The calls of the transaction are guarded the same way. Rollback
+// and Close always wait, so that nothing is left open.
+func WithConcurrencyGuard(mode GuardMode) Option {
+	return func(o *options) {
+		o.guardMode = mode
+	}
+}
+
+// WithResetQuery replaces the query that resets the session
+// of a pinned connection before it is returned to the pool,
+// which by default does what DISCARD ALL does but keeps the prepared
+// statements. Empty query keeps the session as is. See Pool.Acquire.
+//
+// The query must not deallocate the prepared statements, with DISCARD ALL
+// or DEALLOCATE, since the driver caches them per connection and fails
+// to run them afterwards.
+func WithResetQuery(query string) Option {
+	return func(o *options) {
+		o.resetQuery = query
+	}
+}
@@ -0,0 +1,437 @@
+package pgxadapt
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+)
+
+// Pool acquires a connection from the pool for every call,
+// and traces how long it has waited for it with the other fields
+// of the call. See trace.AcquireDurationKey.
+//
+// The connection is returned to the pool once the call is done,
+// except that rows hold it until they are closed, a row returned
+// by QueryRow until it is scanned, and a transaction until it is done.
+type Pool struct {
+	driverPool driver.Pool
+	tracer     trace.Logger
+	opts       *options
+	state      *poolState
+}
+
+type poolState struct {
+	closed atomic.Bool
+}
+
+// NewPool applies WithConcurrencyGuard to the pinned connections
+// and the transactions, which may be shared between goroutines,
+// but not to the other calls, whose connections are never shared.
+func NewPool(driverPool driver.Pool, tracer trace.Logger, opts ...Option) Pool {
+	return Pool{
+		driverPool: driverPool,
+		tracer:     tracer,
+		opts:       newOptions(opts),
+		state:      &poolState{},
+	}
+}
+
+func (p Pool) Exec(
+	ctx context.Context,
+	query string,
+	args ...any,
+) (adapter.Result, error) {
+	if err := p.checkOpen(query); err != nil {
+		return nil, err
+	}
+	driverConn, tracer, err := p.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer driverConn.Release()
+
+	return runExec(driverConn, tracer, p.opts, ctx, query, args...)
+}
+
+func (p Pool) Query(
+	ctx context.Context,
+	query string,
+	args ...any,
+) (adapter.Rows, error) {
+	if err := p.checkOpen(query); err != nil {
+		return nil, err
+	}
+	driverConn, tracer, err := p.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return runQuery(
+		driverConn,
+		tracer,
+		p.opts,
+		driverConn.Release,
+		ctx,
+		query,
+		args...,
+	)
+}
+
+func (p Pool) QueryRow(
+	ctx context.Context,
+	query string,
+	args ...any,
+) adapter.Row {
+	if err := p.checkOpen(query); err != nil {
+		return Row{err: err}
+	}
+	driverConn, tracer, err := p.acquire(ctx, query)
+	if err != nil {
+		return Row{err: err}
+	}
+
+	if p.opts.eagerQueryRow {
+		defer driverConn.Release()
+		return runEagerQueryRow(
+			driverConn,
+			tracer,
+			p.opts,
+			ctx,
+			query,
+			args...,
+		)
+	}
+	return runQueryRow(
+		driverConn,
+		tracer,
+		p.opts,
+		driverConn.Release,
+		ctx,
+		query,
+		args...,
+	)
+}
+
+// Prepare returns a statement that acquires a connection
+// every time it is run.
+func (p Pool) Prepare(ctx context.Context, query string) (adapter.Stmt, error) {
+	if err := p.checkOpen(query); err != nil {
+		return nil, err
+	}
+	return runPrepare(p, p.tracer, ctx, query)
+}
+
+func (p Pool) Begin(ctx context.Context) (adapter.Tx, error) {
+	if err := p.checkOpen(""); err != nil {
+		return nil, err
+	}
+	driverConn, tracer, err := p.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return runBegin(driverConn, tracer, p.opts, nil, driverConn.Release, ctx)
+}
+
+func (p Pool) BeginTx(
+	ctx context.Context,
+	txOptions adapter.TxOptions,
+) (adapter.Tx, error) {
+	if err := p.checkOpen(""); err != nil {
+		return nil, err
+	}
+	driverConn, tracer, err := p.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return runBeginTx(
+		driverConn,
+		tracer,
+		p.opts,
+		driverConn.Release,
+		ctx,
+		txOptions,
+	)
+}
+
+func (p Pool) CommitPrepared(ctx context.Context, globalID string) error {
+	if err := p.checkOpen(""); err != nil {
+		return err
+	}
+	driverConn, tracer, err := p.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer driverConn.Release()
+
+	return runFinishPrepared(
+		driverConn,
+		tracer,
+		p.opts,
+		ctx,
+		"COMMIT PREPARED",
+		globalID,
+		"committed a prepared transaction",
+		"failed to commit a prepared transaction",
+	)
+}
+
+func (p Pool) RollbackPrepared(ctx context.Context, globalID string) error {
+	if err := p.checkOpen(""); err != nil {
+		return err
+	}
+	driverConn, tracer, err := p.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer driverConn.Release()
+
+	return runFinishPrepared(
+		driverConn,
+		tracer,
+		p.opts,
+		ctx,
+		"ROLLBACK PREPARED",
+		globalID,
+		"rolled back a prepared transaction",
+		"failed to rollback a prepared transaction",
+	)
+}
+
+// ListPrepared only returns the transactions prepared
+// in the current database.
+func (p Pool) ListPrepared(ctx context.Context) ([]adapter.PreparedTx, error) {
+	if err := p.checkOpen(listPreparedQuery); err != nil {
+		return nil, err
+	}
+	driverConn, tracer, err := p.acquire(ctx, listPreparedQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer driverConn.Release()
+
+	return runListPrepared(driverConn, tracer, p.opts, ctx)
+}
+
+// Ping acquires a connection and pings it.
+func (p Pool) Ping(ctx context.Context) error {
+	if err := p.checkOpen(""); err != nil {
+		return err
+	}
+	driverConn, tracer, err := p.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer driverConn.Release()
+
+	err = translateError(p.opts, "", driverConn.Ping(ctx))
+	if err != nil {
+		tracer.Log(
+			trace.ErrorLevel,
+			"failed to ping the connection",
+			map[string]any{
+				trace.ErrorKey: err,
+			},
+		)
+		return err
+	}
+
+	tracer.Log(trace.TraceLevel, "pinged the connection", nil)
+	return nil
+}
+
+// Close always returns nil. Closing a closed pool does nothing.
+// It waits for the acquired connections to be returned to the pool.
+func (p Pool) Close() error {
+	if p.state.closed.Swap(true) {
+		return nil
+	}
+
+	p.opts.leaks.report()
+	p.driverPool.Close()
+	return nil
+}
+
+// Acquire traces how long it has waited for the connection
+// with the other fields of every call on it.
+func (p Pool) Acquire(ctx context.Context) (adapter.PinnedConn, error) {
+	if err := p.checkOpen(""); err != nil {
+		return nil, err
+	}
+	driverConn, tracer, err := p.acquire(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tracer.Log(trace.TraceLevel, "acquired a connection", nil)
+
+	conn := PinnedConn{
+		Conn: Conn{
+			driverConn: pooledConn{driverConn},
+			tracer:     tracer,
+			opts:       p.opts,
+			state: &connState{
+				guard: newConnGuard(p.opts.guardMode),
+			},
+		},
+		driverPoolConn: driverConn,
+		acquired:       time.Now(),
+	}
+	return conn, nil
+}
+
+func (p Pool) Stat() adapter.PoolStat {
+	stat := p.driverPool.Stat()
+	return adapter.PoolStat{
+		MaxConns:             int(stat.MaxConns()),
+		TotalConns:           int(stat.TotalConns()),
+		AcquiredConns:        int(stat.AcquiredConns()),
+		IdleConns:            int(stat.IdleConns()),
+		ConstructingConns:    int(stat.ConstructingConns()),
+		AcquireCount:         stat.AcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+	}
+}
+
+// LeakReport returns the transactions and the rows that are still open.
+// It returns nil unless leak detection is enabled. See WithLeakDetection.
+func (p Pool) LeakReport() []Leak {
+	return p.opts.leaks.leaks()
+}
+
+// checkOpen fails fast if the pool is closed.
+func (p Pool) checkOpen(query string) error {
+	if !p.state.closed.Load() {
+		return nil
+	}
+
+	p.tracer.WithCallerSkip(1).
+		Log(trace.ErrorLevel, "used a closed connection", map[string]any{
+			trace.QueryKey: query,
+			trace.ErrorKey: adapter.ErrConnClosed,
+		})
+	return adapter.ErrConnClosed
+}
+
+// acquire returns the tracer of the call, which traces
+// how long the call has waited for the connection.
+func (p Pool) acquire(
+	ctx context.Context,
+	query string,
+) (driver.PoolConn, trace.Logger, error) {
+
+	start := time.Now()
+	driverConn, err := p.driverPool.Acquire(ctx)
+	dur := time.Since(start)
+
+	if err != nil {
+		err = translateError(p.opts, query, err)
+		p.tracer.WithCallerSkip(1).Log(
+			trace.ErrorLevel,
+			"failed to acquire a connection",
+			map[string]any{
+				trace.QueryKey:           query,
+				trace.ErrorKey:           err,
+				trace.AcquireDurationKey: dur,
+			},
+		)
+		return nil, nil, err
+	}
+
+	tracer := p.tracer.With(map[string]any{
+		trace.AcquireDurationKey: dur,
+	})
+	return driverConn, tracer, nil
+}
+
+// defaultResetQuery does what DISCARD ALL does, except deallocating
+// the prepared statements, which the driver caches per connection
+// and would fail to find afterwards.
+const defaultResetQuery = `CLOSE ALL;
+SET SESSION AUTHORIZATION DEFAULT;
+RESET ALL;
+UNLISTEN *;
+SELECT pg_advisory_unlock_all();
+DISCARD PLANS;
+DISCARD TEMP;
+DISCARD SEQUENCES;`
+
+// PinnedConn is a connection acquired from the pool,
+// on which every call runs in the same session until it is released.
+type PinnedConn struct {
+	Conn
+	driverPoolConn driver.PoolConn
+	acquired       time.Time
+}
+
+// Release runs the reset query, see WithResetQuery, and returns
+// the connection to the pool even if the query fails. The pool drops
+// the connection if it is left closed, busy or in a transaction.
+// Releasing a released connection does nothing.
+//
+// Release waits for the connection even if the concurrency guard
+// fails fast, so that the connection is not left acquired.
+func (c PinnedConn) Release(ctx context.Context) error {
+	query := c.opts.resetQuery
+
+	release, err := c.state.guard.wait(ctx)
+	if err != nil {
+		err = translateError(c.opts, query, err)
+		c.tracer.Log(
+			trace.ErrorLevel,
+			"failed to acquire the connection",
+			map[string]any{
+				trace.QueryKey: query,
+				trace.ErrorKey: err,
+			},
+		)
+		return err
+	}
+	defer release()
+	if c.state.closed.Swap(true) {
+		return nil
+	}
+
+	start := time.Now()
+	if query != "" {
+		_, err = c.driverPoolConn.Exec(ctx, query)
+		err = translateError(c.opts, query, err)
+	}
+	c.driverPoolConn.Release()
+
+	fields := map[string]any{
+		trace.DurationKey:        start.Sub(c.acquired),
+		trace.ReleaseDurationKey: time.Since(start),
+	}
+	if err != nil {
+		fields[trace.QueryKey] = query
+		fields[trace.ErrorKey] = err
+		c.tracer.Log(trace.ErrorLevel, "failed to reset a connection", fields)
+		return err
+	}
+
+	c.tracer.Log(trace.TraceLevel, "released a connection", fields)
+	return nil
+}
+
+// Close releases the connection like Release,
+// except that it always returns nil.
+func (c PinnedConn) Close() error {
+	_ = c.Release(context.Background())
+	return nil
+}
+
+// pooledConn makes a connection acquired from the pool a driver.Conn.
+// Closing it does nothing, since it is released instead.
+type pooledConn struct {
+	driver.PoolConn
+}
+
+func (pooledConn) Close() {}
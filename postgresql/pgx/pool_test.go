@@ -0,0 +1,436 @@
+package pgxadapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	mock_driver "github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver/mock"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+	mock_trace "github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace/mock"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	_ adapter.Pool       = Pool{}
+	_ adapter.PinnedConn = PinnedConn{}
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exec", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockConn := mock_driver.NewMockPoolConn(ctrl)
+		gomock.InOrder(
+			mockConn.
+				EXPECT().
+				Exec(gomock.Any(), "SELECT 1").
+				Return(pgconn.NewCommandTag("SELECT 1"), nil),
+			mockConn.
+				EXPECT().
+				Release(),
+		)
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Acquire(gomock.Any()).
+			Return(mockConn, nil)
+
+		mockTracer := mock_trace.NewMockLogger(ctrl)
+		mockTracer.
+			EXPECT().
+			With(gomock.Cond(func(fields map[string]any) bool {
+				_, ok := fields[trace.AcquireDurationKey].(time.Duration)
+				return ok
+			})).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			WithCallerSkip(gomock.Any()).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			With(gomock.Any()).
+			Return(mockTracer)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "executed", gomock.Any())
+
+		pool := NewPool(mockPool, mockTracer)
+
+		_, err := pool.Exec(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRows := mock_driver.NewMockRows(ctrl)
+
+		mockConn := mock_driver.NewMockPoolConn(ctrl)
+		mockConn.
+			EXPECT().
+			Query(gomock.Any(), "SELECT 1").
+			Return(pgxRows{driverRows: mockRows}, nil)
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Acquire(gomock.Any()).
+			Return(mockConn, nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "executed", gomock.Any())
+
+		pool := NewPool(mockPool, mockTracer)
+
+		rows, err := pool.Query(context.Background(), "SELECT 1")
+		require.NoError(t, err)
+
+		// The rows hold the connection until they are closed.
+		gomock.InOrder(
+			mockRows.
+				EXPECT().
+				Close(),
+			mockConn.
+				EXPECT().
+				Release(),
+		)
+		require.NoError(t, rows.Close())
+	})
+
+	t.Run("begin", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+
+		mockConn := mock_driver.NewMockPoolConn(ctrl)
+		mockConn.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{driverTx: mockTx}, nil)
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Acquire(gomock.Any()).
+			Return(mockConn, nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		pool := NewPool(mockPool, mockTracer)
+
+		tx, err := pool.Begin(ctx)
+		require.NoError(t, err)
+
+		// The transaction holds the connection until it is done.
+		gomock.InOrder(
+			mockTx.
+				EXPECT().
+				Commit(gomock.Any()),
+			mockConn.
+				EXPECT().
+				Release(),
+		)
+		require.NoError(t, tx.Commit(ctx))
+	})
+
+	t.Run("acquire_timeout", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Acquire(gomock.Any()).
+			Return(nil, context.DeadlineExceeded)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(
+				trace.ErrorLevel,
+				"failed to acquire a connection",
+				gomock.Cond(func(fields map[string]any) bool {
+					return fields[trace.QueryKey] == "SELECT 1"
+				}),
+			)
+
+		pool := NewPool(mockPool, mockTracer)
+
+		err := pool.QueryRow(context.Background(), "SELECT 1").Scan()
+		require.ErrorIs(t, err, adapter.ErrQueryTimeout)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Close()
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "used a closed connection", gomock.Any())
+
+		pool := NewPool(mockPool, mockTracer)
+		require.NoError(t, pool.Close())
+		require.NoError(t, pool.Close())
+
+		err := pool.Ping(context.Background())
+		require.ErrorIs(t, err, adapter.ErrConnClosed)
+	})
+}
+
+func TestPool_Stat(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockStat := mock_driver.NewMockPoolStat(ctrl)
+	mockStat.EXPECT().MaxConns().Return(int32(10))
+	mockStat.EXPECT().TotalConns().Return(int32(4))
+	mockStat.EXPECT().AcquiredConns().Return(int32(3))
+	mockStat.EXPECT().IdleConns().Return(int32(1))
+	mockStat.EXPECT().ConstructingConns().Return(int32(0))
+	mockStat.EXPECT().AcquireCount().Return(int64(42))
+	mockStat.EXPECT().AcquireDuration().Return(time.Second)
+	mockStat.EXPECT().EmptyAcquireCount().Return(int64(5))
+	mockStat.EXPECT().CanceledAcquireCount().Return(int64(1))
+
+	mockPool := mock_driver.NewMockPool(ctrl)
+	mockPool.
+		EXPECT().
+		Stat().
+		Return(mockStat)
+
+	pool := NewPool(mockPool, mock_trace.NewMockLogger(ctrl))
+
+	require.Equal(t, adapter.PoolStat{
+		MaxConns:             10,
+		TotalConns:           4,
+		AcquiredConns:        3,
+		IdleConns:            1,
+		AcquireCount:         42,
+		AcquireDuration:      time.Second,
+		EmptyAcquireCount:    5,
+		CanceledAcquireCount: 1,
+	}, pool.Stat())
+}
+
+func TestPool_Acquire(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		Opts   []Option
+		Expect func(mockConn *mock_driver.MockPoolConn)
+		Log    string
+		Check  func(err error)
+	}{
+		"default_reset_query": {
+			Expect: func(mockConn *mock_driver.MockPoolConn) {
+				mockConn.
+					EXPECT().
+					Exec(gomock.Any(), defaultResetQuery).
+					Return(pgconn.NewCommandTag("DISCARD"), nil)
+			},
+			Log: "released a connection",
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"custom_reset_query": {
+			Opts: []Option{WithResetQuery("RESET ALL")},
+			Expect: func(mockConn *mock_driver.MockPoolConn) {
+				mockConn.
+					EXPECT().
+					Exec(gomock.Any(), "RESET ALL").
+					Return(pgconn.NewCommandTag("RESET"), nil)
+			},
+			Log: "released a connection",
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"no_reset_query": {
+			Opts:   []Option{WithResetQuery("")},
+			Expect: func(*mock_driver.MockPoolConn) {},
+			Log:    "released a connection",
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"reset_failure": {
+			Expect: func(mockConn *mock_driver.MockPoolConn) {
+				mockConn.
+					EXPECT().
+					Exec(gomock.Any(), defaultResetQuery).
+					Return(pgconn.CommandTag{}, &pgconn.PgError{
+						Code: pgerrcode.ActiveSQLTransaction,
+					})
+			},
+			Log: "failed to reset a connection",
+			Check: func(err error) {
+				var pgErr *pgconn.PgError
+				require.ErrorAs(t, err, &pgErr)
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			mockConn := mock_driver.NewMockPoolConn(ctrl)
+			mockConn.
+				EXPECT().
+				Exec(gomock.Any(), "SET search_path TO tenant").
+				Return(pgconn.NewCommandTag("SET"), nil)
+			mockConn.
+				EXPECT().
+				Exec(gomock.Any(), "SELECT 1").
+				Return(pgconn.NewCommandTag("SELECT 1"), nil)
+			testCase.Expect(mockConn)
+			mockConn.
+				EXPECT().
+				Release()
+
+			// Every call on the pinned connection runs
+			// on the connection it has acquired once.
+			mockPool := mock_driver.NewMockPool(ctrl)
+			mockPool.
+				EXPECT().
+				Acquire(gomock.Any()).
+				Return(mockConn, nil)
+
+			mockTracer := newPermissiveTracer(ctrl)
+			mockTracer.
+				EXPECT().
+				Log(trace.TraceLevel, "acquired a connection", nil)
+			mockTracer.
+				EXPECT().
+				Log(trace.TraceLevel, "executed", gomock.Any()).
+				Times(2)
+			mockTracer.
+				EXPECT().
+				Log(gomock.Any(), testCase.Log, gomock.Cond(
+					func(fields map[string]any) bool {
+						_, ok := fields[trace.ReleaseDurationKey]
+						return ok
+					},
+				))
+			mockTracer.
+				EXPECT().
+				Log(trace.ErrorLevel, "used a closed connection", gomock.Any())
+
+			ctx := context.Background()
+			pool := NewPool(mockPool, mockTracer, testCase.Opts...)
+
+			conn, err := pool.Acquire(ctx)
+			require.NoError(t, err)
+
+			_, err = conn.Exec(ctx, "SET search_path TO tenant")
+			require.NoError(t, err)
+			_, err = conn.Exec(ctx, "SELECT 1")
+			require.NoError(t, err)
+
+			testCase.Check(conn.Release(ctx))
+			require.NoError(t, conn.Release(ctx))
+			require.NoError(t, conn.Close())
+
+			_, err = conn.Exec(ctx, "SELECT 1")
+			require.ErrorIs(t, err, adapter.ErrConnClosed)
+		})
+	}
+
+	// Close waits for the rows instead of leaving the connection
+	// acquired, even if the concurrency guard fails fast.
+	t.Run("close_busy", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRows := mock_driver.NewMockRows(ctrl)
+
+		mockConn := mock_driver.NewMockPoolConn(ctrl)
+		mockConn.
+			EXPECT().
+			Query(gomock.Any(), "SELECT 1").
+			Return(pgxRows{driverRows: mockRows}, nil)
+		gomock.InOrder(
+			mockRows.
+				EXPECT().
+				Close(),
+			mockConn.
+				EXPECT().
+				Release(),
+		)
+
+		mockPool := mock_driver.NewMockPool(ctrl)
+		mockPool.
+			EXPECT().
+			Acquire(gomock.Any()).
+			Return(mockConn, nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		pool := NewPool(
+			mockPool,
+			mockTracer,
+			WithConcurrencyGuard(GuardFailFast),
+			WithResetQuery(""),
+		)
+
+		conn, err := pool.Acquire(ctx)
+		require.NoError(t, err)
+		rows, err := conn.Query(ctx, "SELECT 1")
+		require.NoError(t, err)
+
+		closed := make(chan error)
+		go func() {
+			closed <- conn.Close()
+		}()
+
+		select {
+		case <-closed:
+			t.Fatal("the connection has been released with the rows open")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		require.NoError(t, rows.Close())
+		require.NoError(t, <-closed)
+	})
+}
+
+// The prepared statements are cached by the driver, which fails
+// to run them once they are deallocated.
+func TestDefaultResetQuery(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, defaultResetQuery, "DEALLOCATE")
+	require.NotContains(t, defaultResetQuery, "DISCARD ALL")
+}
@@ -0,0 +1,298 @@
+package pgxadapt
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/errs"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Only the transactions prepared in the current database
+// can be committed or rolled back through the connection.
+const listPreparedQuery = `SELECT gid, prepared, owner::text, database::text
+FROM pg_prepared_xacts
+WHERE database = current_database()
+ORDER BY prepared`
+
+// maxGlobalIDLen is the maximum length of a global transaction id
+// in bytes, excluding the terminating zero byte.
+const maxGlobalIDLen = 199
+
+// PrepareTransaction returns adapter.ErrPrepareNestedTx
+// if the transaction is nested. Neither the commit nor the rollback
+// hooks run once the transaction is prepared.
+func (t Tx) PrepareTransaction(ctx context.Context, globalID string) error {
+	if err := t.checkActive(""); err != nil {
+		return err
+	}
+
+	var err error
+	switch {
+	case t.state.parent != nil:
+		err = adapter.ErrPrepareNestedTx
+	case !isValidGlobalID(globalID):
+		err = adapter.ErrInvalidGlobalID
+	}
+	if err != nil {
+		t.tracer.Log(
+			trace.ErrorLevel,
+			"failed to prepare a transaction",
+			map[string]any{
+				trace.ErrorKey:    err,
+				trace.GlobalIDKey: globalID,
+			},
+		)
+		return err
+	}
+
+	release, err := t.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+	if !t.state.finish() {
+		return t.state.doneErr()
+	}
+
+	query := "PREPARE TRANSACTION " + quoteGlobalID(globalID)
+	_, err = t.driverTx.Exec(ctx, query)
+	t.state.release()
+	err = translateGlobalIDError(t.opts, query, err)
+
+	fields := t.summary(time.Since(t.state.start))
+	fields[trace.GlobalIDKey] = globalID
+
+	if err != nil {
+		fields[trace.ErrorKey] = err
+		t.tracer.Log(
+			trace.ErrorLevel,
+			"failed to prepare a transaction",
+			fields,
+		)
+
+		// The server rolls the transaction back if it fails to prepare it.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			t.runRollbackHooks(ctx, err)
+		}
+		return err
+	}
+
+	t.tracer.Log(trace.TraceLevel, "prepared a transaction", fields)
+
+	t.state.mu.Lock()
+	t.state.onCommit, t.state.onRollback = nil, nil
+	t.state.mu.Unlock()
+	return nil
+}
+
+func (c Conn) CommitPrepared(ctx context.Context, globalID string) error {
+	if err := c.checkOpen(""); err != nil {
+		return err
+	}
+	release, err := c.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return runFinishPrepared(
+		c.driverConn,
+		c.tracer,
+		c.opts,
+		ctx,
+		"COMMIT PREPARED",
+		globalID,
+		"committed a prepared transaction",
+		"failed to commit a prepared transaction",
+	)
+}
+
+func (c Conn) RollbackPrepared(ctx context.Context, globalID string) error {
+	if err := c.checkOpen(""); err != nil {
+		return err
+	}
+	release, err := c.acquire(ctx, "")
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return runFinishPrepared(
+		c.driverConn,
+		c.tracer,
+		c.opts,
+		ctx,
+		"ROLLBACK PREPARED",
+		globalID,
+		"rolled back a prepared transaction",
+		"failed to rollback a prepared transaction",
+	)
+}
+
+// ListPrepared only returns the transactions prepared
+// in the current database.
+func (c Conn) ListPrepared(ctx context.Context) ([]adapter.PreparedTx, error) {
+	if err := c.checkOpen(listPreparedQuery); err != nil {
+		return nil, err
+	}
+	release, err := c.acquire(ctx, listPreparedQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return runListPrepared(c.driverConn, c.tracer, c.opts, ctx)
+}
+
+func runListPrepared(
+	querier driver.Querier,
+	tracer trace.Logger,
+	opts *options,
+	ctx context.Context,
+) ([]adapter.PreparedTx, error) {
+
+	tracer = tracer.WithCallerSkip(1).With(map[string]any{
+		trace.QueryKey: listPreparedQuery,
+	})
+
+	start := time.Now()
+	prepared, err := listPrepared(querier, ctx)
+	dur := time.Since(start)
+
+	if err != nil {
+		err = translateError(opts, listPreparedQuery, err)
+		tracer.Log(
+			trace.ErrorLevel,
+			"failed to list prepared transactions",
+			map[string]any{
+				trace.ErrorKey: err,
+			},
+		)
+		return nil, err
+	}
+
+	tracer.Log(
+		trace.TraceLevel,
+		"listed prepared transactions",
+		map[string]any{
+			trace.ResultKey:   len(prepared),
+			trace.DurationKey: dur,
+		},
+	)
+	return prepared, nil
+}
+
+// runFinishPrepared runs the command, either "COMMIT PREPARED"
+// or "ROLLBACK PREPARED", with the quoted global id.
+func runFinishPrepared(
+	execer driver.Execer,
+	tracer trace.Logger,
+	opts *options,
+	ctx context.Context,
+	command string,
+	globalID string,
+	message string,
+	failureMessage string,
+) error {
+
+	tracer = tracer.WithCallerSkip(1).With(map[string]any{
+		trace.GlobalIDKey: globalID,
+	})
+
+	if !isValidGlobalID(globalID) {
+		tracer.Log(trace.ErrorLevel, failureMessage, map[string]any{
+			trace.ErrorKey: adapter.ErrInvalidGlobalID,
+		})
+		return adapter.ErrInvalidGlobalID
+	}
+
+	query := command + " " + quoteGlobalID(globalID)
+
+	start := time.Now()
+	_, err := execer.Exec(ctx, query)
+	dur := time.Since(start)
+
+	if err != nil {
+		err = translateGlobalIDError(opts, query, err)
+		tracer.Log(trace.ErrorLevel, failureMessage, map[string]any{
+			trace.ErrorKey: err,
+		})
+		return err
+	}
+
+	tracer.Log(trace.TraceLevel, message, map[string]any{
+		trace.DurationKey: dur,
+	})
+	return nil
+}
+
+func listPrepared(
+	querier driver.Querier,
+	ctx context.Context,
+) ([]adapter.PreparedTx, error) {
+
+	driverRows, err := querier.Query(ctx, listPreparedQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer driverRows.Close()
+
+	var prepared []adapter.PreparedTx
+	for driverRows.Next() {
+		var tx adapter.PreparedTx
+		err = driverRows.Scan(
+			&tx.GlobalID,
+			&tx.Prepared,
+			&tx.Owner,
+			&tx.Database,
+		)
+		if err != nil {
+			return nil, err
+		}
+		prepared = append(prepared, tx)
+	}
+	return prepared, driverRows.Err()
+}
+
+// translateGlobalIDError maps the errors of the commands
+// that take a global id, whose SQLSTATE codes are too generic
+// to be mapped by the error translator.
+func translateGlobalIDError(opts *options, query string, err error) error {
+	err = translateError(opts, query, err)
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgerrcode.UndefinedObject:
+		return errs.New(adapter.ErrUnknownGlobalID, err)
+	case pgerrcode.DuplicateObject:
+		return errs.New(adapter.ErrDuplicateGlobalID, err)
+	}
+	return err
+}
+
+// isValidGlobalID reports whether the global id can be quoted
+// by quoteGlobalID. Backslashes are rejected, since the server reads
+// them as escapes unless standard_conforming_strings is on.
+func isValidGlobalID(globalID string) bool {
+	return globalID != "" &&
+		len(globalID) <= maxGlobalIDLen &&
+		!strings.ContainsAny(globalID, "\x00\\")
+}
+
+// quoteGlobalID quotes the global id as a string literal,
+// since the commands do not accept parameters.
+func quoteGlobalID(globalID string) string {
+	return "'" + strings.ReplaceAll(globalID, "'", "''") + "'"
+}
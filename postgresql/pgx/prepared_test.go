@@ -0,0 +1,243 @@
+package pgxadapt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	mock_driver "github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver/mock"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTx_PrepareTransaction(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `PREPARE TRANSACTION 'order''s 1'`).
+			Return(pgconn.NewCommandTag("PREPARE TRANSACTION"), nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "prepared a transaction", gomock.Cond(
+				func(fields map[string]any) bool {
+					return fields[trace.GlobalIDKey] == "order's 1"
+				},
+			))
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "used a finished transaction", gomock.Any())
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer)
+		tx.OnCommit(func(context.Context) {
+			t.Fatal("commit hook must not run")
+		})
+
+		require.NoError(t, tx.PrepareTransaction(ctx, "order's 1"))
+		require.ErrorIs(t, tx.Commit(ctx), adapter.ErrTxDone)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Exec(gomock.Any(), `PREPARE TRANSACTION 'order'`).
+			Return(pgconn.CommandTag{}, &pgconn.PgError{
+				Code: pgerrcode.DuplicateObject,
+			})
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(
+				trace.ErrorLevel,
+				"failed to prepare a transaction",
+				gomock.Any(),
+			)
+
+		tx := NewTx(mockTx, mockTracer)
+
+		err := tx.PrepareTransaction(context.Background(), "order")
+		require.ErrorIs(t, err, adapter.ErrDuplicateGlobalID)
+
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{}, nil)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(
+				trace.ErrorLevel,
+				"failed to prepare a transaction",
+				gomock.Any(),
+			).
+			Times(2)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "began a transaction", gomock.Any())
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer)
+
+		err := tx.PrepareTransaction(ctx, "")
+		require.ErrorIs(t, err, adapter.ErrInvalidGlobalID)
+
+		nestedTx, err := tx.Begin(ctx)
+		require.NoError(t, err)
+
+		err = nestedTx.PrepareTransaction(ctx, "order")
+		require.ErrorIs(t, err, adapter.ErrPrepareNestedTx)
+	})
+}
+
+func TestConn_CommitPrepared(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockConn := mock_driver.NewMockConn(ctrl)
+	mockConn.
+		EXPECT().
+		Exec(gomock.Any(), `COMMIT PREPARED 'order'`).
+		Return(pgconn.NewCommandTag("COMMIT PREPARED"), nil)
+	mockConn.
+		EXPECT().
+		Exec(gomock.Any(), `COMMIT PREPARED 'unknown'`).
+		Return(pgconn.CommandTag{}, &pgconn.PgError{
+			Code: pgerrcode.UndefinedObject,
+		})
+
+	mockTracer := newPermissiveTracer(ctrl)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "committed a prepared transaction", gomock.Any())
+	mockTracer.
+		EXPECT().
+		Log(
+			trace.ErrorLevel,
+			"failed to commit a prepared transaction",
+			gomock.Any(),
+		).
+		Times(3)
+
+	ctx := context.Background()
+	conn := NewConn(mockConn, mockTracer)
+
+	require.NoError(t, conn.CommitPrepared(ctx, "order"))
+
+	err := conn.CommitPrepared(ctx, "unknown")
+	require.ErrorIs(t, err, adapter.ErrUnknownGlobalID)
+
+	err = conn.CommitPrepared(ctx, string([]byte{0}))
+	require.ErrorIs(t, err, adapter.ErrInvalidGlobalID)
+	err = conn.CommitPrepared(ctx, `order\' OR '1`)
+	require.ErrorIs(t, err, adapter.ErrInvalidGlobalID)
+}
+
+func TestConn_RollbackPrepared(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	mockConn := mock_driver.NewMockConn(ctrl)
+	mockConn.
+		EXPECT().
+		Exec(gomock.Any(), `ROLLBACK PREPARED 'order'`).
+		Return(pgconn.NewCommandTag("ROLLBACK PREPARED"), nil)
+
+	mockTracer := newPermissiveTracer(ctrl)
+	mockTracer.
+		EXPECT().
+		Log(
+			trace.TraceLevel,
+			"rolled back a prepared transaction",
+			gomock.Any(),
+		)
+
+	conn := NewConn(mockConn, mockTracer)
+	require.NoError(t, conn.RollbackPrepared(context.Background(), "order"))
+}
+
+func TestConn_ListPrepared(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	prepared := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mockRows := mock_driver.NewMockRows(ctrl)
+	gomock.InOrder(
+		mockRows.
+			EXPECT().
+			Next().
+			Return(true),
+		mockRows.
+			EXPECT().
+			Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(dest ...any) error {
+				*dest[0].(*string) = "order"
+				*dest[1].(*time.Time) = prepared
+				*dest[2].(*string) = "postgres"
+				*dest[3].(*string) = "shop"
+				return nil
+			}),
+		mockRows.
+			EXPECT().
+			Next().
+			Return(false),
+		mockRows.
+			EXPECT().
+			Err().
+			Return(nil),
+		mockRows.
+			EXPECT().
+			Close(),
+	)
+
+	mockConn := mock_driver.NewMockConn(ctrl)
+	mockConn.
+		EXPECT().
+		Query(gomock.Any(), listPreparedQuery).
+		Return(pgxRows{driverRows: mockRows}, nil)
+
+	mockTracer := newPermissiveTracer(ctrl)
+	mockTracer.
+		EXPECT().
+		Log(trace.TraceLevel, "listed prepared transactions", gomock.Any())
+
+	conn := NewConn(mockConn, mockTracer)
+
+	txs, err := conn.ListPrepared(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []adapter.PreparedTx{
+		{
+			GlobalID: "order",
+			Prepared: prepared,
+			Owner:    "postgres",
+			Database: "shop",
+		},
+	}, txs)
+}
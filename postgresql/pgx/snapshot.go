@@ -0,0 +1,182 @@
+package pgxadapt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/errs"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const exportSnapshotQuery = "SELECT pg_export_snapshot()"
+
+// snapshots maps the ids of the snapshots exported in the process
+// to whether the transactions that have exported them are still open,
+// so that importing the snapshot of a finished transaction fails
+// without a round trip.
+var snapshots = struct {
+	mu   sync.Mutex
+	open map[string]bool
+}{open: make(map[string]bool)}
+
+// ExportSnapshot returns the id of the snapshot, which stays valid
+// only as long as the transaction is open.
+func (t Tx) ExportSnapshot(ctx context.Context) (string, error) {
+	if err := t.checkActive(exportSnapshotQuery); err != nil {
+		return "", err
+	}
+	release, err := t.acquire(ctx, exportSnapshotQuery)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if err = t.state.enter(exportSnapshotQuery); err != nil {
+		return "", err
+	}
+	defer t.state.leave()
+
+	var snapshot string
+
+	start := time.Now()
+	err = t.driverTx.QueryRow(ctx, exportSnapshotQuery).Scan(&snapshot)
+	dur := time.Since(start)
+
+	if err != nil {
+		err = translateError(t.opts, exportSnapshotQuery, err)
+		t.tracer.Log(
+			trace.ErrorLevel,
+			"failed to export a snapshot",
+			map[string]any{trace.ErrorKey: err},
+		)
+		return "", err
+	}
+	t.state.record(dur, 0)
+
+	// The snapshot lasts as long as the outermost transaction.
+	registerSnapshot(snapshot, t.state.root())
+
+	t.tracer.Log(trace.TraceLevel, "exported a snapshot", map[string]any{
+		trace.SnapshotKey: snapshot,
+		trace.DurationKey: dur,
+	})
+	return snapshot, nil
+}
+
+// registerSnapshot also forgets the snapshots of the finished
+// transactions, so that the registry does not grow unbounded.
+func registerSnapshot(snapshot string, exporter *txState) {
+	snapshots.mu.Lock()
+	for id, open := range snapshots.open {
+		if !open {
+			delete(snapshots.open, id)
+		}
+	}
+	snapshots.open[snapshot] = true
+	snapshots.mu.Unlock()
+
+	// The exporter may have finished meanwhile.
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	if exporter.done.Load() {
+		closeSnapshots([]string{snapshot})
+		return
+	}
+	exporter.snapshots = append(exporter.snapshots, snapshot)
+}
+
+// forgetSnapshots marks the snapshots exported by the transaction
+// as closed once it is done, without keeping the transaction.
+func (s *txState) forgetSnapshots() {
+	s.mu.Lock()
+	ids := s.snapshots
+	s.snapshots = nil
+	s.mu.Unlock()
+
+	closeSnapshots(ids)
+}
+
+func closeSnapshots(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := snapshots.open[id]; ok {
+			snapshots.open[id] = false
+		}
+	}
+}
+
+// isSnapshotAlive reports false if the snapshot has been exported
+// in the process by a transaction that is already done. The snapshots
+// exported elsewhere are left for the server to check.
+func isSnapshotAlive(snapshot string) bool {
+	snapshots.mu.Lock()
+	defer snapshots.mu.Unlock()
+
+	open, ok := snapshots.open[snapshot]
+	if !ok {
+		return true
+	}
+	if !open {
+		delete(snapshots.open, snapshot)
+	}
+	return open
+}
+
+// setSnapshot makes the transaction import the snapshot, which has
+// been validated by toPgxTxOptions. It does nothing if the snapshot
+// is empty.
+func (t Tx) setSnapshot(ctx context.Context, snapshot string) error {
+	if snapshot == "" {
+		return nil
+	}
+	if !isSnapshotAlive(snapshot) {
+		return adapter.ErrSnapshotInvalid
+	}
+
+	// SET TRANSACTION does not accept parameters.
+	query := "SET TRANSACTION SNAPSHOT '" + snapshot + "'"
+	start := time.Now()
+	_, err := t.driverTx.Exec(ctx, query)
+	t.state.record(time.Since(start), 0)
+
+	// The server reports an unknown or a malformed id
+	// as an invalid parameter value.
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) &&
+		pgErr.Code == pgerrcode.InvalidParameterValue {
+
+		return errs.New(adapter.ErrSnapshotInvalid, err)
+	}
+	return translateError(t.opts, query, err)
+}
+
+// isValidSnapshot reports whether the id looks like the ones returned
+// by pg_export_snapshot, i.e. hexadecimal numbers separated by dashes.
+func isValidSnapshot(snapshot string) bool {
+	if snapshot == "" || len(snapshot) > maxIdentifierLen {
+		return false
+	}
+
+	prev := '-'
+	for _, r := range snapshot {
+		switch {
+		case r == '-' && prev != '-':
+		case '0' <= r && r <= '9', 'A' <= r && r <= 'F', 'a' <= r && r <= 'f':
+		default:
+			return false
+		}
+		prev = r
+	}
+	return prev != '-'
+}
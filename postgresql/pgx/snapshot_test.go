@@ -0,0 +1,180 @@
+package pgxadapt
+
+import (
+	"context"
+	"testing"
+
+	adapter "github.com/adanyl0v/go-sql-adapter"
+	mock_driver "github.com/adanyl0v/go-sql-adapter/postgresql/pgx/driver/mock"
+	"github.com/adanyl0v/go-sql-adapter/postgresql/pgx/trace"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTx_ExportSnapshot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		const snapshot = "00000003-0000001B-2"
+
+		mockRow := mock_driver.NewMockRow(ctrl)
+		mockRow.
+			EXPECT().
+			Scan(gomock.Any()).
+			DoAndReturn(func(dest ...any) error {
+				*dest[0].(*string) = snapshot
+				return nil
+			})
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			QueryRow(gomock.Any(), exportSnapshotQuery).
+			Return(mockRow)
+		mockTx.
+			EXPECT().
+			Commit(gomock.Any())
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "exported a snapshot", gomock.Cond(
+				func(fields map[string]any) bool {
+					return fields[trace.SnapshotKey] == snapshot
+				},
+			))
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, "committed a transaction", gomock.Any())
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer)
+
+		exported, err := tx.ExportSnapshot(ctx)
+		require.NoError(t, err)
+		require.Equal(t, snapshot, exported)
+
+		mockImporter := mock_driver.NewMockTx(ctrl)
+		mockImporter.
+			EXPECT().
+			Exec(gomock.Any(), "SET TRANSACTION SNAPSHOT '"+snapshot+"'")
+		importer := NewTx(mockImporter, mockTracer)
+		require.NoError(t, importer.setSnapshot(ctx, snapshot))
+
+		// The snapshot is gone with the exporting transaction.
+		require.NoError(t, tx.Commit(ctx))
+		require.ErrorIs(
+			t,
+			importer.setSnapshot(ctx, snapshot),
+			adapter.ErrSnapshotInvalid,
+		)
+	})
+
+	// The snapshot of a nested transaction lasts
+	// until the outermost one is done.
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		const snapshot = "00000003-0000001B-3"
+
+		mockRow := mock_driver.NewMockRow(ctrl)
+		mockRow.
+			EXPECT().
+			Scan(gomock.Any()).
+			DoAndReturn(func(dest ...any) error {
+				*dest[0].(*string) = snapshot
+				return nil
+			})
+
+		mockNestedTx := mock_driver.NewMockTx(ctrl)
+		mockNestedTx.
+			EXPECT().
+			QueryRow(gomock.Any(), exportSnapshotQuery).
+			Return(mockRow)
+		mockNestedTx.
+			EXPECT().
+			Commit(gomock.Any())
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			Begin(gomock.Any()).
+			Return(pgxTx{driverTx: mockNestedTx}, nil)
+		mockTx.
+			EXPECT().
+			Rollback(gomock.Any())
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.TraceLevel, gomock.Any(), gomock.Any()).
+			AnyTimes()
+
+		ctx := context.Background()
+		tx := NewTx(mockTx, mockTracer)
+
+		nestedTx, err := tx.Begin(ctx)
+		require.NoError(t, err)
+		_, err = nestedTx.ExportSnapshot(ctx)
+		require.NoError(t, err)
+		require.NoError(t, nestedTx.Commit(ctx))
+		require.True(t, isSnapshotAlive(snapshot))
+
+		require.NoError(t, tx.Rollback(ctx))
+		require.False(t, isSnapshotAlive(snapshot))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+
+		mockRow := mock_driver.NewMockRow(ctrl)
+		mockRow.
+			EXPECT().
+			Scan(gomock.Any()).
+			Return(&pgconn.PgError{Code: pgerrcode.FeatureNotSupported})
+
+		mockTx := mock_driver.NewMockTx(ctrl)
+		mockTx.
+			EXPECT().
+			QueryRow(gomock.Any(), exportSnapshotQuery).
+			Return(mockRow)
+
+		mockTracer := newPermissiveTracer(ctrl)
+		mockTracer.
+			EXPECT().
+			Log(trace.ErrorLevel, "failed to export a snapshot", gomock.Any())
+
+		tx := NewTx(mockTx, mockTracer)
+
+		_, err := tx.ExportSnapshot(context.Background())
+
+		var pgErr *pgconn.PgError
+		require.ErrorAs(t, err, &pgErr)
+	})
+}
+
+func TestIsValidSnapshot(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]bool{
+		"00000003-0000001B-1": true,
+		"3-1B-1":              true,
+		"":                    false,
+		"-00000003":           false,
+		"00000003-":           false,
+		"00000003--1":         false,
+		"00000003-0000001G-1": false,
+		"'; SELECT 1; --":     false,
+	}
+
+	for snapshot, expected := range testCases {
+		require.Equal(t, expected, isValidSnapshot(snapshot), snapshot)
+	}
+}
@@ -9,10 +9,28 @@ const (
 )
 
 const (
-	ErrorKey    = "error"
-	QueryKey    = "query"
-	ResultKey   = "result"
-	DurationKey = "duration"
+	ErrorKey     = "error"
+	QueryKey     = "query"
+	ResultKey    = "result"
+	DurationKey  = "duration"
+	TxOptionsKey = "tx_options"
+	AttemptKey   = "attempt"
+	SavepointKey = "savepoint"
+	PanicKey     = "panic"
+	StackKey     = "stack"
+	LeakKey      = "leak"
+	IdleKey      = "idle"
+
+	TxIDKey         = "tx_id"
+	TxDepthKey      = "tx_depth"
+	QueryCountKey   = "query_count"
+	DBTimeKey       = "db_time"
+	RowsAffectedKey = "rows_affected"
+	GlobalIDKey     = "global_id"
+	SnapshotKey     = "snapshot"
+
+	AcquireDurationKey = "acquire_duration"
+	ReleaseDurationKey = "release_duration"
 )
 
 type Logger interface {
@@ -0,0 +1,128 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy tells RunInTxWithRetry when and how soon
+// to run a failed transaction again.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is doubled after every attempt, up to MaxDelay,
+	// and then jittered. Zero MaxDelay leaves the delay unbounded.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Classifier reports whether the transaction that failed with err
+	// should be run again. Nil means IsSerializationConflict.
+	Classifier func(err error) bool
+}
+
+// IsSerializationConflict reports whether the transaction failed
+// because of a concurrent transaction and has been rolled back,
+// so that the whole transaction can be run again.
+func IsSerializationConflict(err error) bool {
+	return errors.Is(err, ErrSerializationFailure) ||
+		errors.Is(err, ErrDeadlockDetected)
+}
+
+// RunInTxWithRetry runs fn in a transaction like RunInTx, and runs
+// the whole transaction again while the policy allows it.
+//
+// A nested transaction is not run again, because its failure has to be
+// handled by its parent, so if beginner is nil, RunInTxWithRetry
+// is the same as RunInTx.
+//
+// It stops when ctx is done, and returns the error of the last attempt
+// joined with the error of ctx. The number of the attempt and the error
+// of the previous one are passed to the beginner through the context.
+// See TxAttemptFromContext.
+func RunInTxWithRetry(
+	ctx context.Context,
+	beginner TxBeginner,
+	opts TxOptions,
+	policy RetryPolicy,
+	fn func(ctx context.Context, tx Tx) error,
+) error {
+
+	if beginner == nil {
+		return RunInTx(ctx, beginner, opts, fn)
+	}
+
+	classifier := policy.Classifier
+	if classifier == nil {
+		classifier = IsSerializationConflict
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		attemptCtx := context.WithValue(ctx, txAttemptKey{}, txAttempt{
+			number: attempt,
+			cause:  err,
+		})
+
+		err = RunInTx(attemptCtx, beginner, opts, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !classifier(err) {
+			return err
+		}
+
+		if ctxErr := sleep(ctx, policy.delay(attempt)); ctxErr != nil {
+			return errors.Join(err, ctxErr)
+		}
+	}
+}
+
+// delay returns the delay after the attempt,
+// which is chosen at random from the upper half of the backoff.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	backoff := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		if p.MaxDelay > 0 && backoff >= p.MaxDelay ||
+			backoff > math.MaxInt64/2 {
+			break
+		}
+		backoff *= 2
+	}
+
+	if p.MaxDelay > 0 && backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	half := backoff / 2
+	return half + rand.N(backoff-half)
+}
+
+// sleep returns the error of ctx if it is done before the delay is over.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type txAttemptKey struct{}
+
+type txAttempt struct {
+	number int
+	cause  error
+}
+
+// TxAttemptFromContext returns the number of the attempt
+// to run a transaction and the error of the previous attempt.
+// It returns 0 and nil outside of RunInTxWithRetry.
+func TxAttemptFromContext(ctx context.Context) (attempt int, cause error) {
+	a, _ := ctx.Value(txAttemptKey{}).(txAttempt)
+	return a.number, a.cause
+}
@@ -0,0 +1,218 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		Policy  RetryPolicy
+		Attempt int
+		Backoff time.Duration
+	}{
+		"first_attempt": {
+			Policy:  RetryPolicy{BaseDelay: 100 * time.Millisecond},
+			Attempt: 1,
+			Backoff: 100 * time.Millisecond,
+		},
+		"doubled": {
+			Policy:  RetryPolicy{BaseDelay: 100 * time.Millisecond},
+			Attempt: 4,
+			Backoff: 800 * time.Millisecond,
+		},
+		"max_delay": {
+			Policy: RetryPolicy{
+				BaseDelay: 100 * time.Millisecond,
+				MaxDelay:  time.Second,
+			},
+			Attempt: 10,
+			Backoff: time.Second,
+		},
+		"max_delay_below_base_delay": {
+			Policy: RetryPolicy{
+				BaseDelay: time.Second,
+				MaxDelay:  100 * time.Millisecond,
+			},
+			Attempt: 1,
+			Backoff: 100 * time.Millisecond,
+		},
+		// The backoff stops doubling before it overflows.
+		"overflow": {
+			Policy:  RetryPolicy{BaseDelay: time.Second},
+			Attempt: 100,
+			Backoff: time.Second << 33,
+		},
+		"overflow_max_delay": {
+			Policy: RetryPolicy{
+				BaseDelay: time.Second,
+				MaxDelay:  math.MaxInt64,
+			},
+			Attempt: math.MaxInt32,
+			Backoff: time.Second << 33,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for range 100 {
+				delay := testCase.Policy.delay(testCase.Attempt)
+				require.GreaterOrEqual(t, delay, testCase.Backoff/2)
+				require.Less(t, delay, testCase.Backoff)
+			}
+		})
+	}
+
+	t.Run("no_delay", func(t *testing.T) {
+		t.Parallel()
+		require.Zero(t, RetryPolicy{}.delay(3))
+	})
+}
+
+func TestRunInTxWithRetry(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		Policy     RetryPolicy
+		CommitErrs []error
+		Attempts   int
+		Check      func(err error)
+	}{
+		"success_after_retries": {
+			Policy: RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+			},
+			CommitErrs: []error{
+				ErrSerializationFailure,
+				ErrDeadlockDetected,
+				nil,
+			},
+			Attempts: 3,
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"attempts_exhausted": {
+			Policy: RetryPolicy{
+				MaxAttempts: 2,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			},
+			CommitErrs: []error{
+				ErrSerializationFailure,
+				ErrSerializationFailure,
+			},
+			Attempts: 2,
+			Check: func(err error) {
+				require.ErrorIs(t, err, ErrSerializationFailure)
+			},
+		},
+		"not_retryable": {
+			Policy: RetryPolicy{
+				MaxAttempts: 3,
+			},
+			CommitErrs: []error{ErrUniqueViolation},
+			Attempts:   1,
+			Check: func(err error) {
+				require.ErrorIs(t, err, ErrUniqueViolation)
+			},
+		},
+		"retries_disabled": {
+			CommitErrs: []error{ErrSerializationFailure},
+			Attempts:   1,
+			Check: func(err error) {
+				require.ErrorIs(t, err, ErrSerializationFailure)
+			},
+		},
+		"custom_classifier": {
+			Policy: RetryPolicy{
+				MaxAttempts: 2,
+				Classifier: func(err error) bool {
+					return errors.Is(err, ErrLockNotAvailable)
+				},
+			},
+			CommitErrs: []error{ErrLockNotAvailable, nil},
+			Attempts:   2,
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				begun    int
+				attempts int
+				lastErr  error
+			)
+			beginner := beginnerFunc(
+				func(ctx context.Context, _ TxOptions) (Tx, error) {
+					attempt, cause := TxAttemptFromContext(ctx)
+					require.Equal(t, begun+1, attempt)
+					require.Equal(t, lastErr, cause)
+
+					tx := &fakeTx{commitErr: testCase.CommitErrs[begun]}
+					lastErr = tx.commitErr
+					begun++
+					return tx, nil
+				},
+			)
+
+			err := RunInTxWithRetry(
+				context.Background(),
+				beginner,
+				TxOptions{},
+				testCase.Policy,
+				func(ctx context.Context, _ Tx) error {
+					attempts++
+
+					attempt, _ := TxAttemptFromContext(ctx)
+					require.Equal(t, attempts, attempt)
+					return nil
+				},
+			)
+			testCase.Check(err)
+			require.Equal(t, testCase.Attempts, attempts)
+		})
+	}
+
+	t.Run("context_canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		beginner := beginnerFunc(func(context.Context, TxOptions) (Tx, error) {
+			cancel()
+			return &fakeTx{commitErr: ErrSerializationFailure}, nil
+		})
+
+		err := RunInTxWithRetry(
+			ctx,
+			beginner,
+			TxOptions{},
+			RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Hour,
+			},
+			func(context.Context, Tx) error {
+				return nil
+			},
+		)
+		require.ErrorIs(t, err, ErrSerializationFailure)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+)
+
+// TxBeginner begins transactions. Both Conn and Tx implement it,
+// the latter by beginning nested transactions.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts TxOptions) (Tx, error)
+}
+
+type txKey struct{}
+
+// ContextWithTx returns a copy of ctx that carries tx.
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// QuerierFromContext returns the transaction carried by ctx,
+// or fallback if there is none, so that the code deep in a call chain
+// joins the transaction of its caller.
+func QuerierFromContext(ctx context.Context, fallback Querier) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return fallback
+}
+
+// RunInTx runs fn in a transaction, which is committed if fn returns nil
+// and rolled back otherwise. If fn panics, the transaction is rolled back
+// and the panic is resumed.
+//
+// The transaction is carried by the context passed to fn. If beginner
+// is nil, RunInTx begins a nested transaction from the one carried
+// by ctx, or fails with ErrNoTx if there is none, and opts must be zero.
+// Otherwise, the transaction is begun by beginner even if ctx carries
+// another one, which may be on another database.
+//
+// The rollback error is joined with the error of fn. fn must not commit
+// or roll back the transaction itself: RunInTx cannot tell whether fn
+// has committed it, so it returns ErrTxDone if fn returns nil.
+func RunInTx(
+	ctx context.Context,
+	beginner TxBeginner,
+	opts TxOptions,
+	fn func(ctx context.Context, tx Tx) error,
+) error {
+
+	if beginner == nil {
+		parent, ok := TxFromContext(ctx)
+		if !ok {
+			return ErrNoTx
+		}
+		beginner = parent
+	}
+
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// The transaction must be rolled back even if ctx is done.
+	rollbackCtx := context.WithoutCancel(ctx)
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(rollbackCtx)
+			panic(p)
+		}
+	}()
+
+	if err = fn(ContextWithTx(ctx, tx), tx); err != nil {
+		rollbackErr := tx.Rollback(rollbackCtx)
+		if rollbackErr != nil && !errors.Is(rollbackErr, ErrTxDone) {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
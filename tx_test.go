@@ -0,0 +1,354 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records how it is finished. It only implements the methods
+// RunInTx needs, the others panic.
+type fakeTx struct {
+	Tx
+	commitErr   error
+	rollbackErr error
+	commits     int
+	rollbacks   int
+	done        bool
+	nested      []*fakeTx
+}
+
+func (t *fakeTx) BeginTx(context.Context, TxOptions) (Tx, error) {
+	nestedTx := &fakeTx{}
+	t.nested = append(t.nested, nestedTx)
+	return nestedTx, nil
+}
+
+func (t *fakeTx) Commit(context.Context) error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	t.commits++
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback(context.Context) error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	t.rollbacks++
+	return t.rollbackErr
+}
+
+type beginnerFunc func(ctx context.Context, opts TxOptions) (Tx, error)
+
+func (f beginnerFunc) BeginTx(ctx context.Context, opts TxOptions) (Tx, error) {
+	return f(ctx, opts)
+}
+
+// beginTx returns a beginner that always begins tx.
+func beginTx(tx *fakeTx) TxBeginner {
+	return beginnerFunc(func(context.Context, TxOptions) (Tx, error) {
+		return tx, nil
+	})
+}
+
+func TestRunInTx(t *testing.T) {
+	t.Parallel()
+
+	fnErr := errors.New("fn")
+
+	testCases := map[string]struct {
+		Tx        fakeTx
+		Fn        func(ctx context.Context, tx Tx) error
+		Commits   int
+		Rollbacks int
+		Check     func(err error)
+	}{
+		"commit": {
+			Fn: func(context.Context, Tx) error {
+				return nil
+			},
+			Commits: 1,
+			Check: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		"commit_failure": {
+			Tx: fakeTx{commitErr: ErrSerializationFailure},
+			Fn: func(context.Context, Tx) error {
+				return nil
+			},
+			Commits: 1,
+			Check: func(err error) {
+				require.ErrorIs(t, err, ErrSerializationFailure)
+			},
+		},
+		"rollback": {
+			Fn: func(context.Context, Tx) error {
+				return fnErr
+			},
+			Rollbacks: 1,
+			Check: func(err error) {
+				require.Equal(t, fnErr, err)
+			},
+		},
+		"rollback_failure": {
+			Tx: fakeTx{rollbackErr: io.ErrUnexpectedEOF},
+			Fn: func(context.Context, Tx) error {
+				return fnErr
+			},
+			Rollbacks: 1,
+			Check: func(err error) {
+				require.ErrorIs(t, err, fnErr)
+				require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			},
+		},
+		"committed_by_fn": {
+			Fn: func(ctx context.Context, tx Tx) error {
+				return tx.Commit(ctx)
+			},
+			Commits: 1,
+			Check: func(err error) {
+				require.ErrorIs(t, err, ErrTxDone)
+			},
+		},
+		"rolled_back_by_fn": {
+			Fn: func(ctx context.Context, tx Tx) error {
+				return tx.Rollback(ctx)
+			},
+			Rollbacks: 1,
+			Check: func(err error) {
+				require.ErrorIs(t, err, ErrTxDone)
+			},
+		},
+		"rolled_back_by_fn_with_error": {
+			Fn: func(ctx context.Context, tx Tx) error {
+				_ = tx.Rollback(ctx)
+				return fnErr
+			},
+			Rollbacks: 1,
+			Check: func(err error) {
+				require.Equal(t, fnErr, err)
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tx := &testCase.Tx
+			err := RunInTx(
+				context.Background(),
+				beginTx(tx),
+				TxOptions{},
+				func(ctx context.Context, fnTx Tx) error {
+					require.Same(t, tx, fnTx)
+
+					ctxTx, ok := TxFromContext(ctx)
+					require.True(t, ok)
+					require.Same(t, tx, ctxTx)
+
+					return testCase.Fn(ctx, fnTx)
+				},
+			)
+			testCase.Check(err)
+			require.Equal(t, testCase.Commits, tx.commits)
+			require.Equal(t, testCase.Rollbacks, tx.rollbacks)
+		})
+	}
+
+	t.Run("begin_failure", func(t *testing.T) {
+		t.Parallel()
+
+		beginner := beginnerFunc(func(context.Context, TxOptions) (Tx, error) {
+			return nil, ErrConnBusy
+		})
+
+		err := RunInTx(
+			context.Background(),
+			beginner,
+			TxOptions{},
+			func(context.Context, Tx) error {
+				t.Fatal("fn must not be called")
+				return nil
+			},
+		)
+		require.ErrorIs(t, err, ErrConnBusy)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		t.Parallel()
+
+		tx := &fakeTx{}
+		require.PanicsWithValue(t, "fn", func() {
+			_ = RunInTx(
+				context.Background(),
+				beginTx(tx),
+				TxOptions{},
+				func(context.Context, Tx) error {
+					panic("fn")
+				},
+			)
+		})
+		require.Equal(t, 1, tx.rollbacks)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var rollbackCtx context.Context
+		tx := &fakeTx{}
+		beginner := beginnerFunc(func(context.Context, TxOptions) (Tx, error) {
+			return rollbackCtxTx{fakeTx: tx, ctx: &rollbackCtx}, nil
+		})
+
+		err := RunInTx(
+			ctx,
+			beginner,
+			TxOptions{},
+			func(context.Context, Tx) error {
+				cancel()
+				return fnErr
+			},
+		)
+		require.Equal(t, fnErr, err)
+		require.Equal(t, 1, tx.rollbacks)
+
+		// The transaction is rolled back even though ctx is done.
+		require.NoError(t, rollbackCtx.Err())
+	})
+}
+
+// rollbackCtxTx records the context the transaction is rolled back with.
+type rollbackCtxTx struct {
+	*fakeTx
+	ctx *context.Context
+}
+
+func (t rollbackCtxTx) Rollback(ctx context.Context) error {
+	*t.ctx = ctx
+	return t.fakeTx.Rollback(ctx)
+}
+
+func TestRunInTx_Nested(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil_beginner", func(t *testing.T) {
+		t.Parallel()
+
+		tx := &fakeTx{}
+		fallback := &fakeTx{}
+		nestedErr := errors.New("nested")
+
+		err := RunInTx(
+			context.Background(),
+			beginTx(tx),
+			TxOptions{},
+			func(ctx context.Context, tx Tx) error {
+				require.Same(t, tx, QuerierFromContext(ctx, fallback))
+
+				err := RunInTxWithRetry(
+					ctx,
+					nil,
+					TxOptions{},
+					RetryPolicy{
+						MaxAttempts: 3,
+						Classifier: func(error) bool {
+							return true
+						},
+					},
+					func(ctx context.Context, nestedTx Tx) error {
+						require.NotSame(t, tx, nestedTx)
+						require.Same(
+							t,
+							nestedTx,
+							QuerierFromContext(ctx, fallback),
+						)
+						return nestedErr
+					},
+				)
+				require.Equal(t, nestedErr, err)
+				return nil
+			},
+		)
+		require.NoError(t, err)
+
+		// The nested transaction is begun from the one carried
+		// by the context, and is not run again.
+		require.Len(t, tx.nested, 1)
+		require.Equal(t, 1, tx.nested[0].rollbacks)
+		require.Equal(t, 1, tx.commits)
+	})
+
+	t.Run("other_beginner", func(t *testing.T) {
+		t.Parallel()
+
+		tx := &fakeTx{}
+		other := &fakeTx{}
+
+		err := RunInTx(
+			context.Background(),
+			beginTx(tx),
+			TxOptions{},
+			func(ctx context.Context, _ Tx) error {
+				return RunInTx(
+					ctx,
+					beginTx(other),
+					TxOptions{},
+					func(ctx context.Context, otherTx Tx) error {
+						require.Same(t, other, otherTx)
+						require.Same(t, other, QuerierFromContext(ctx, nil))
+						return nil
+					},
+				)
+			},
+		)
+		require.NoError(t, err)
+
+		// The beginner is used even though the context carries
+		// a transaction, which may be on another database.
+		require.Empty(t, tx.nested)
+		require.Equal(t, 1, tx.commits)
+		require.Equal(t, 1, other.commits)
+	})
+
+	t.Run("no_tx", func(t *testing.T) {
+		t.Parallel()
+
+		err := RunInTx(
+			context.Background(),
+			nil,
+			TxOptions{},
+			func(context.Context, Tx) error {
+				t.Fatal("fn must not be called")
+				return nil
+			},
+		)
+		require.ErrorIs(t, err, ErrNoTx)
+	})
+}
+
+func TestQuerierFromContext(t *testing.T) {
+	t.Parallel()
+
+	tx := &fakeTx{}
+	fallback := &fakeTx{}
+
+	ctx := context.Background()
+	require.Same(t, fallback, QuerierFromContext(ctx, fallback))
+
+	_, ok := TxFromContext(ctx)
+	require.False(t, ok)
+
+	ctx = ContextWithTx(ctx, tx)
+	require.Same(t, tx, QuerierFromContext(ctx, fallback))
+}